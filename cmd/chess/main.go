@@ -3,23 +3,43 @@ package main
 import (
 	"flag"
 	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/user/chess/pkg/board"
 	"github.com/user/chess/pkg/game"
 	"github.com/user/chess/pkg/ui"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "perft" {
+		if err := runPerft(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			fmt.Println("Usage: chess perft <depth> [fen]")
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Command line flags
-	playerNames := flag.String("names", "Player1,Player2", "Names of the two players (comma-separated)")
+	playerNames := flag.String("names", "Player1,Player2", "Names of the players (comma-separated), four for Bughouse")
 	saveFile := flag.String("save", "", "Save game to specified file")
 	loadFile := flag.String("load", "", "Load game from specified file")
+	gameNumber := flag.Int("game", 1, "Number of the game to load from a PGN file")
 	ascii := flag.Bool("ascii", false, "Use ASCII characters instead of Unicode")
 	help := flag.Bool("help", false, "Show help message")
 	timeControl := flag.String("time", "10,5", "Time control in minutes,increment_seconds (e.g., '10,5' for 10 minutes + 5 seconds increment)")
 	noTimer := flag.Bool("no-timer", false, "Disable time control")
+	takeback := flag.String("takeback", "allowed", "Takeback policy for undo and redo: allowed, consent or disabled")
+	variantName := flag.String("variant", "standard", "Variant to play: "+variantNames())
+	startPosition := flag.Int("position", -1, "Chess960 starting position from 0 to 959, or -1 for a random one")
+	host := flag.String("host", "", "Host a network game on the given address (e.g., ':7777') and play White")
+	connect := flag.String("connect", "", "Join the network game hosted at the given address (e.g., 'localhost:7777') and play Black")
 
 	flag.Parse()
 
@@ -27,6 +47,9 @@ func main() {
 		fmt.Println("Chess Game in Go")
 		fmt.Println("\nOptions:")
 		flag.PrintDefaults()
+		fmt.Println("\nCommands:")
+		fmt.Println("  perft <depth> [fen]")
+		fmt.Println("    \tCount the legal moves to the given depth, split by first move")
 		os.Exit(0)
 	}
 
@@ -39,8 +62,32 @@ func main() {
 		blackName = strings.TrimSpace(names[1])
 	}
 
+	takebackPolicy, err := ui.ParseTakebackPolicy(*takeback)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	variant, err := game.ParseVariant(*variantName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	// Create new game
-	g := game.NewGame()
+	options := []game.Option{game.WithVariant(variant)}
+	if variant == game.Chess960 {
+		n := *startPosition
+		if n < 0 {
+			n = rand.Intn(board.Chess960Positions)
+		} else if n >= board.Chess960Positions {
+			fmt.Printf("Invalid Chess960 position %d, must be from 0 to 959\n", n)
+			os.Exit(1)
+		}
+		fmt.Printf("Chess960 starting position %d\n", n)
+		options = append(options, game.WithStartPosition(n))
+	}
+	g := game.NewGame(options...)
 
 	// Configure time control
 	if !*noTimer {
@@ -59,20 +106,95 @@ func main() {
 		g.TimeControl = nil
 	}
 
+	// Bughouse is a match of four players on two boards
+	if variant == game.Bughouse {
+		for _, name := range []string{"load", "save", "host", "connect"} {
+			if isFlagSet(name) {
+				fmt.Printf("The -%s option cannot be used with Bughouse\n", name)
+				os.Exit(1)
+			}
+		}
+		playBughouse(names, g.TimeControl, *ascii)
+		return
+	}
+
 	// Load game if specified
 	if *loadFile != "" {
-		err := g.LoadGame(*loadFile)
+		if strings.EqualFold(filepath.Ext(*loadFile), ".pgn") {
+			err = g.LoadPGN(*loadFile, *gameNumber)
+		} else {
+			err = g.LoadGame(*loadFile)
+		}
 		if err != nil {
 			fmt.Printf("Error loading game: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("Game loaded from %s\n", *loadFile)
+
+		// Keep the names stored in the file unless others were given
+		if !isFlagSet("names") {
+			if g.WhitePlayer != "" {
+				whiteName = g.WhitePlayer
+			}
+			if g.BlackPlayer != "" {
+				blackName = g.BlackPlayer
+			}
+		}
+	}
+
+	// Host or join a network game. The host sends the game, with its
+	// variant, names and time control, to the player who joins. A hidden
+	// game stays on the host, and the guest only sees what it is sent.
+	var conn *ui.Connection
+	var hidden *ui.HiddenGame
+	remoteColor := board.Black
+	switch {
+	case *connect != "":
+		conn, err = ui.Connect(*connect)
+		if err == nil {
+			g, hidden, err = conn.ReceiveGame()
+		}
+		if err == nil && g != nil {
+			if g.WhitePlayer != "" {
+				whiteName = g.WhitePlayer
+			}
+			if g.BlackPlayer != "" {
+				blackName = g.BlackPlayer
+			}
+			remoteColor = board.White
+		}
+	case *host != "":
+		g.SetPlayerNames(whiteName, blackName)
+		fmt.Printf("Waiting for an opponent on %s\n", *host)
+		conn, err = ui.Host(*host)
+		if err == nil {
+			err = conn.SendGame(g)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Network error: %v\n", err)
+		os.Exit(1)
+	}
+	if hidden != nil {
+		defer conn.Close()
+		if *saveFile != "" {
+			fmt.Printf("The game of %s is kept on the host's computer and cannot be saved here\n", hidden.Variant.Name())
+		}
+		guest := ui.NewGuestUI(conn, hidden)
+		guest.SetAsciiMode(*ascii)
+		guest.Start()
+		return
 	}
 
 	// Create UI
 	ui := ui.NewUI(g)
 	ui.SetAsciiMode(*ascii)
 	ui.SetPlayerNames(whiteName, blackName)
+	ui.SetTakebackPolicy(takebackPolicy)
+	if conn != nil {
+		defer conn.Close()
+		ui.SetRemote(remoteColor, conn)
+	}
 
 	// Start the game
 	ui.Start()
@@ -87,3 +209,89 @@ func main() {
 		fmt.Printf("Game saved to %s\n", *saveFile)
 	}
 }
+
+// playBughouse plays a Bughouse match on the terminal. names holds the
+// players of board 1, White then Black, followed by those of board 2. Both
+// boards get the given time control.
+func playBughouse(names []string, timeControl *game.TimeControl, ascii bool) {
+	players := []string{"Player1", "Player2", "Player3", "Player4"}
+	if len(names) >= 4 {
+		for i := range players {
+			players[i] = strings.TrimSpace(names[i])
+		}
+	}
+
+	match := game.NewMatch()
+	bughouseUI := ui.NewBughouseUI(match)
+	bughouseUI.SetAsciiMode(ascii)
+	for i := 0; i < 2; i++ {
+		match.Board(i).TimeControl = nil
+		if timeControl != nil {
+			clocks := *timeControl
+			match.Board(i).TimeControl = &clocks
+		}
+		bughouseUI.SetPlayerNames(i, players[2*i], players[2*i+1])
+	}
+	bughouseUI.Start()
+}
+
+// runPerft counts the move tree of a position to the given depth, printing
+// the count below each move. The position defaults to the starting position.
+func runPerft(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing depth")
+	}
+	depth, err := strconv.Atoi(args[0])
+	if err != nil || depth < 1 {
+		return fmt.Errorf("invalid depth %q", args[0])
+	}
+
+	fen := game.StartFEN
+	if len(args) > 1 {
+		// Allow the FEN to be passed with or without quotes
+		fen = strings.Join(args[1:], " ")
+	}
+	g, err := game.NewGameFromFEN(fen)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	counts := g.Divide(depth)
+	elapsed := time.Since(start)
+
+	moves := make([]string, 0, len(counts))
+	total := 0
+	for move, nodes := range counts {
+		moves = append(moves, move)
+		total += nodes
+	}
+	sort.Strings(moves)
+	for _, move := range moves {
+		fmt.Printf("%s: %d\n", move, counts[move])
+	}
+	fmt.Printf("\nNodes searched: %d\n", total)
+	fmt.Printf("Time: %v\n", elapsed.Round(time.Millisecond))
+	return nil
+}
+
+// variantNames returns the names of the variants that can be played,
+// separated by commas
+func variantNames() string {
+	var names []string
+	for _, v := range game.Variants() {
+		names = append(names, strings.ToLower(v.Name()))
+	}
+	return strings.Join(names, ", ")
+}
+
+// isFlagSet reports whether a flag was given on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
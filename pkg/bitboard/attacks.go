@@ -0,0 +1,211 @@
+package bitboard
+
+import (
+	"github.com/user/chess/pkg/board"
+)
+
+// Attack tables for the pieces that do not slide
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+	pawnAttacks   [3][64]Bitboard // Indexed by the color of the pawn
+)
+
+// magic finds the attacks of a slider on one square. The occupied squares
+// that can block the slider are multiplied by the magic number, and the top
+// bits of the product index a table of attack sets.
+type magic struct {
+	mask    Bitboard
+	magic   uint64
+	shift   uint
+	attacks []Bitboard
+}
+
+func (m *magic) index(occupied Bitboard) uint64 {
+	return uint64(occupied&m.mask) * m.magic >> m.shift
+}
+
+var (
+	rookMagics   [64]magic
+	bishopMagics [64]magic
+)
+
+type direction struct{ row, col int }
+
+var (
+	rookDirections   = []direction{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	bishopDirections = []direction{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+	knightOffsets    = []direction{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+	kingOffsets      = []direction{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+)
+
+func init() {
+	for s := Square(0); s < 64; s++ {
+		knightAttacks[s] = offsetAttacks(s, knightOffsets)
+		kingAttacks[s] = offsetAttacks(s, kingOffsets)
+		pawnAttacks[board.White][s] = offsetAttacks(s, []direction{{-1, -1}, {-1, 1}})
+		pawnAttacks[board.Black][s] = offsetAttacks(s, []direction{{1, -1}, {1, 1}})
+	}
+
+	for s := Square(0); s < 64; s++ {
+		initMagic(&rookMagics[s], s, rookDirections, rookMagicNumbers[s])
+		initMagic(&bishopMagics[s], s, bishopDirections, bishopMagicNumbers[s])
+	}
+}
+
+// offsetAttacks returns the squares at the given offsets that are on the board
+func offsetAttacks(s Square, offsets []direction) Bitboard {
+	var attacks Bitboard
+	for _, d := range offsets {
+		row, col := s.Row()+d.row, s.Col()+d.col
+		if row >= 0 && row < 8 && col >= 0 && col < 8 {
+			attacks |= SquareAt(row, col).Bitboard()
+		}
+	}
+	return attacks
+}
+
+// slidingAttacks walks each direction from s until it leaves the board or
+// reaches an occupied square, which is included
+func slidingAttacks(s Square, occupied Bitboard, directions []direction) Bitboard {
+	var attacks Bitboard
+	for _, d := range directions {
+		row, col := s.Row()+d.row, s.Col()+d.col
+		for row >= 0 && row < 8 && col >= 0 && col < 8 {
+			target := SquareAt(row, col)
+			attacks |= target.Bitboard()
+			if occupied.Has(target) {
+				break
+			}
+			row, col = row+d.row, col+d.col
+		}
+	}
+	return attacks
+}
+
+// magicMask returns the squares whose pieces can block a slider on s.
+// Pieces on the edge of the board never block anything beyond them, unless
+// the slider is on that edge itself.
+func magicMask(s Square, directions []direction) Bitboard {
+	edges := ((row0 | row7) &^ (row0 << (8 * uint(s.Row())))) |
+		((fileA | fileH) &^ (fileA << uint(s.Col())))
+	return slidingAttacks(s, 0, directions) &^ edges
+}
+
+// initMagic fills the attack table of a slider on s. It panics if the magic
+// number maps two different attack sets to the same entry.
+func initMagic(m *magic, s Square, directions []direction, number uint64) {
+	m.mask = magicMask(s, directions)
+	m.magic = number
+	m.shift = uint(64 - m.mask.Count())
+	m.attacks = make([]Bitboard, 1<<uint(m.mask.Count()))
+	filled := make([]bool, len(m.attacks))
+
+	// Visit every subset of the mask with the carry-rippler trick
+	subset := Bitboard(0)
+	for {
+		attacks := slidingAttacks(s, subset, directions)
+		index := m.index(subset)
+		if filled[index] && m.attacks[index] != attacks {
+			panic("bitboard: bad magic number for square " + s.String())
+		}
+		m.attacks[index] = attacks
+		filled[index] = true
+
+		subset = (subset - m.mask) & m.mask
+		if subset == 0 {
+			return
+		}
+	}
+}
+
+// Magic numbers for each square, found with findMagic in the tests
+var rookMagicNumbers = [64]uint64{
+	0x018010a040018000, 0x0040002000401001, 0x290010a841e00100, 0x29001000050900a0,
+	0x4080030400800800, 0x1200040200100801, 0x2200208200040851, 0x220000820425004c,
+	0x8450800080400c22, 0x0802401000200143, 0x0100802000100084, 0x2881002100100009,
+	0x4009000410080100, 0x0003000400020900, 0x4804000810020104, 0x0074800641800900,
+	0x0080004000402000, 0x1c90004040002000, 0x4000430020010113, 0x82c501000b100120,
+	0x0848808004020800, 0x4522808004000200, 0x0000010100020004, 0x400206000092411c,
+	0x818004444000a000, 0x0180a000c0005002, 0x000b104100200100, 0x24022202000a4010,
+	0x0100040080080080, 0x5206040080800200, 0x0020010400100802, 0x0410008200010044,
+	0x0310400089800020, 0x08c0804009002902, 0x1004402001001504, 0x0105021001000920,
+	0x0000040080800801, 0x0a02001002000804, 0x0108284204005041, 0x0008004082002411,
+	0x02802281c0028001, 0x0009044000910020, 0x0000200010008080, 0x0040201001010008,
+	0x8000080004008080, 0x3010400420080110, 0x0000414210040008, 0x0010348400460001,
+	0x0080002000401040, 0x0460200088400080, 0x8201822000100280, 0x0600100008008280,
+	0x00c0800800040080, 0x0024040080020080, 0x22c11a0108100c00, 0x0204008114104200,
+	0x8800800010290041, 0x0000401500228206, 0x8002a00011090041, 0x0000042008100101,
+	0x0283000800100205, 0x0002008810010402, 0x0490102200880104, 0x4010808844050222,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x12602202285c0080, 0x0010900200404441, 0xa810012041089080, 0x0024410020322045,
+	0x8004042004019000, 0x0008220820802804, 0x0a01008211404100, 0x2010250808010808,
+	0x0000131010208480, 0x1100204404508020, 0x0a241010d0910004, 0x4008040400900100,
+	0x8800440420520c00, 0x0014020243200040, 0x04208a1804420800, 0x00401d0120900482,
+	0x00c00020840400c0, 0x1c03302008062080, 0x012800011a040050, 0x0088000082004400,
+	0x1004002480a00012, 0x0000202202012004, 0x0008822100905010, 0x0241010040421000,
+	0x0820110020040184, 0x040828026102008a, 0xc000b00008054240, 0x0008080100202020,
+	0x0801001181004000, 0x1040450006101204, 0x0200820404010490, 0x4200802501140600,
+	0x00100220808a0830, 0x2048420800900100, 0x0500241004110104, 0x6000202020080080,
+	0x0c240042002c0108, 0x2410004280011001, 0x005102420a840100, 0x00208e0240048424,
+	0xc808041009000434, 0x00020221240a6000, 0x0080084050024800, 0x0800410401000821,
+	0x00010208a2004400, 0x0502081008204104, 0x028a900542064100, 0x8814080208200244,
+	0x0008920820840000, 0x1900240108080000, 0x0400220114094002, 0x2904200020a80004,
+	0x0080414110411501, 0x0008e04424082010, 0x0410841000820290, 0x4c08080080aa0000,
+	0x0040202110086080, 0x1000402201100800, 0x00020001008090a0, 0x00c01000c8420200,
+	0xe01000c012020201, 0x1000001012104502, 0x2800208411480111, 0x8010013020860040,
+}
+
+// KnightAttacks returns the squares a knight on s attacks
+func KnightAttacks(s Square) Bitboard {
+	return knightAttacks[s]
+}
+
+// KingAttacks returns the squares a king on s attacks
+func KingAttacks(s Square) Bitboard {
+	return kingAttacks[s]
+}
+
+// PawnAttacks returns the squares a pawn of the given color on s attacks
+func PawnAttacks(s Square, color board.Color) Bitboard {
+	return pawnAttacks[color][s]
+}
+
+// RookAttacks returns the squares a rook on s attacks, given the occupied squares
+func RookAttacks(s Square, occupied Bitboard) Bitboard {
+	m := &rookMagics[s]
+	return m.attacks[m.index(occupied)]
+}
+
+// BishopAttacks returns the squares a bishop on s attacks, given the occupied squares
+func BishopAttacks(s Square, occupied Bitboard) Bitboard {
+	m := &bishopMagics[s]
+	return m.attacks[m.index(occupied)]
+}
+
+// QueenAttacks returns the squares a queen on s attacks, given the occupied squares
+func QueenAttacks(s Square, occupied Bitboard) Bitboard {
+	return RookAttacks(s, occupied) | BishopAttacks(s, occupied)
+}
+
+// Attacks returns the squares a piece of the given type and color on s
+// attacks, given the occupied squares
+func Attacks(p board.Piece, s Square, occupied Bitboard) Bitboard {
+	switch p.Type {
+	case board.Pawn:
+		return PawnAttacks(s, p.Color)
+	case board.Knight:
+		return KnightAttacks(s)
+	case board.Bishop:
+		return BishopAttacks(s, occupied)
+	case board.Rook:
+		return RookAttacks(s, occupied)
+	case board.Queen:
+		return QueenAttacks(s, occupied)
+	case board.King:
+		return KingAttacks(s)
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package bitboard
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// findMagic searches for a magic number for a slider on s. Numbers with few
+// bits set make good magics, so candidates are ANDs of random numbers. The
+// tables in attacks.go were generated with one generator seeded with 1,
+// calling it for each square in turn, rook before bishop.
+func findMagic(s Square, directions []direction, rng *rand.Rand) uint64 {
+	mask := magicMask(s, directions)
+	shift := uint(64 - mask.Count())
+
+	var occupancies, attacks []Bitboard
+	subset := Bitboard(0)
+	for {
+		occupancies = append(occupancies, subset)
+		attacks = append(attacks, slidingAttacks(s, subset, directions))
+		subset = (subset - mask) & mask
+		if subset == 0 {
+			break
+		}
+	}
+
+	table := make([]Bitboard, len(occupancies))
+	used := make([]int, len(occupancies)) // The attempt that last filled each entry
+	for attempt := 1; ; attempt++ {
+		number := rng.Uint64() & rng.Uint64() & rng.Uint64()
+		if bits.OnesCount64(uint64(mask)*number>>56) < 6 {
+			continue
+		}
+
+		ok := true
+		for i, occupied := range occupancies {
+			index := uint64(occupied) * number >> shift
+			if used[index] != attempt {
+				used[index] = attempt
+				table[index] = attacks[i]
+			} else if table[index] != attacks[i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return number
+		}
+	}
+}
+
+func TestFindMagic(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, s := range []Square{0, 9, 27, 63} {
+		var m magic
+		// initMagic panics if the number does not work
+		initMagic(&m, s, rookDirections, findMagic(s, rookDirections, rng))
+		initMagic(&m, s, bishopDirections, findMagic(s, bishopDirections, rng))
+	}
+}
+
+func TestSliderAttacksMatchRayWalk(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for s := Square(0); s < 64; s++ {
+		for i := 0; i < 200; i++ {
+			// Sparse and dense boards both matter for blockers
+			occupied := Bitboard(rng.Uint64() & rng.Uint64())
+			if i%2 == 1 {
+				occupied = Bitboard(rng.Uint64())
+			}
+
+			if got, want := RookAttacks(s, occupied), slidingAttacks(s, occupied, rookDirections); got != want {
+				t.Fatalf("RookAttacks(%v, %#x) = %#x, want %#x", s, uint64(occupied), uint64(got), uint64(want))
+			}
+			if got, want := BishopAttacks(s, occupied), slidingAttacks(s, occupied, bishopDirections); got != want {
+				t.Fatalf("BishopAttacks(%v, %#x) = %#x, want %#x", s, uint64(occupied), uint64(got), uint64(want))
+			}
+		}
+	}
+}
+
+func TestLeaperAttacks(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Bitboard
+		want []string
+	}{
+		{"knight a1", KnightAttacks(square(t, "a1")), []string{"b3", "c2"}},
+		{"knight d4", KnightAttacks(square(t, "d4")), []string{"b3", "b5", "c2", "c6", "e2", "e6", "f3", "f5"}},
+		{"king h8", KingAttacks(square(t, "h8")), []string{"g7", "g8", "h7"}},
+		{"white pawn e2", PawnAttacks(square(t, "e2"), 1), []string{"d3", "f3"}},
+		{"black pawn a7", PawnAttacks(square(t, "a7"), 2), []string{"b6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want Bitboard
+			for _, name := range tt.want {
+				want |= square(t, name).Bitboard()
+			}
+			if tt.got != want {
+				t.Errorf("attacks = %v, want %v", tt.got.Squares(), want.Squares())
+			}
+		})
+	}
+}
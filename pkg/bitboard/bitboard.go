@@ -0,0 +1,112 @@
+// Package bitboard represents chess positions as sets of 64-bit boards,
+// one bit per square, for fast move generation.
+//
+// Squares are numbered like board.Position: square 0 is a8, square 7 is h8
+// and square 63 is h1, so a square's row and column are its index divided
+// by eight and its remainder. Sliding piece attacks are looked up with
+// magic bitboards; knight, king and pawn attacks come from tables.
+package bitboard
+
+import (
+	"math/bits"
+
+	"github.com/user/chess/pkg/board"
+)
+
+// Bitboard is a set of squares
+type Bitboard uint64
+
+// Square is a square of the board, from 0 (a8) to 63 (h1)
+type Square int
+
+// NoSquare is used when there is no square, such as no en passant target
+const NoSquare Square = -1
+
+// Rows and files as bitboards
+const (
+	fileA Bitboard = 0x0101010101010101
+	fileH Bitboard = fileA << 7
+	row0  Bitboard = 0xff // Rank 8
+	row7  Bitboard = row0 << 56
+)
+
+// SquareAt returns the square on the given row and column
+func SquareAt(row, col int) Square {
+	return Square(row*8 + col)
+}
+
+// SquareOf returns the square of a board position
+func SquareOf(pos board.Position) Square {
+	return SquareAt(pos.Row, pos.Col)
+}
+
+// Row returns the row of the square, 0 being rank 8
+func (s Square) Row() int {
+	return int(s) / 8
+}
+
+// Col returns the column of the square, 0 being the a-file
+func (s Square) Col() int {
+	return int(s) % 8
+}
+
+// Position returns the square as a board position
+func (s Square) Position() board.Position {
+	return board.Position{Row: s.Row(), Col: s.Col()}
+}
+
+// Bitboard returns a bitboard holding only this square
+func (s Square) Bitboard() Bitboard {
+	return 1 << uint(s)
+}
+
+// String returns the square in algebraic notation, or "-" for NoSquare
+func (s Square) String() string {
+	if s == NoSquare {
+		return "-"
+	}
+	return s.Position().String()
+}
+
+// Has reports whether the square is in the set
+func (b Bitboard) Has(s Square) bool {
+	return b&s.Bitboard() != 0
+}
+
+// Count returns the number of squares in the set
+func (b Bitboard) Count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// First returns the lowest numbered square of the set, or NoSquare if the
+// set is empty
+func (b Bitboard) First() Square {
+	if b == 0 {
+		return NoSquare
+	}
+	return Square(bits.TrailingZeros64(uint64(b)))
+}
+
+// PopFirst removes the lowest numbered square from the set and returns it
+func (b *Bitboard) PopFirst() Square {
+	s := b.First()
+	*b &= *b - 1
+	return s
+}
+
+// Squares returns the squares of the set in increasing order
+func (b Bitboard) Squares() []Square {
+	squares := make([]Square, 0, b.Count())
+	for b != 0 {
+		squares = append(squares, b.PopFirst())
+	}
+	return squares
+}
+
+// opponentOf returns the other color
+func opponentOf(color board.Color) board.Color {
+	if color == board.White {
+		return board.Black
+	}
+	return board.White
+}
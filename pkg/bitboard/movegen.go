@@ -0,0 +1,570 @@
+package bitboard
+
+import (
+	"strings"
+
+	"github.com/user/chess/pkg/board"
+)
+
+// Move is a move from one square to another. Promotion is the piece a pawn
+// promotes to, or board.Empty. Castling is written as the king's move, or
+// in Chess960 as the king capturing its own rook. In Crazyhouse, Drop is
+// the type of the piece put on To from the pocket, and From is NoSquare.
+type Move struct {
+	From      Square
+	To        Square
+	Promotion board.PieceType
+	Drop      board.PieceType
+}
+
+// String returns the move in coordinate notation, such as "e2e4" or
+// "e7e8q", or a drop such as "N@f3"
+func (m Move) String() string {
+	if m.Drop != board.Empty {
+		return board.Piece{Type: m.Drop, Color: board.White}.ASCIIString() + "@" + m.To.String()
+	}
+	s := m.From.String() + m.To.String()
+	if m.Promotion != board.Empty {
+		s += strings.ToLower(board.Piece{Type: m.Promotion, Color: board.White}.ASCIIString())
+	}
+	return s
+}
+
+// promotionTypes lists the pieces a pawn may promote to, in the order moves
+// are generated. In Antichess a pawn may also promote to a king.
+var (
+	promotionTypes          = []board.PieceType{board.Queen, board.Rook, board.Bishop, board.Knight}
+	antichessPromotionTypes = []board.PieceType{board.Queen, board.Rook, board.Bishop, board.Knight, board.King}
+)
+
+// PseudoLegalMoves appends the moves of the side to move to moves, without
+// checking whether they leave the king in check. Castling is only generated
+// when it is fully legal. Drops come last.
+func (p *Position) PseudoLegalMoves(moves []Move) []Move {
+	us := p.side
+	own := p.byColor[us]
+	enemies := p.byColor[opponentOf(us)]
+	occupied := own | enemies
+
+	moves = p.pawnMoves(moves, enemies, occupied)
+
+	for _, pieceType := range []board.PieceType{board.Knight, board.Bishop, board.Rook, board.Queen, board.King} {
+		pieces := p.Pieces(us, pieceType)
+		for pieces != 0 {
+			from := pieces.PopFirst()
+			targets := Attacks(board.Piece{Type: pieceType, Color: us}, from, occupied) &^ own
+			for targets != 0 {
+				moves = append(moves, Move{From: from, To: targets.PopFirst()})
+			}
+		}
+	}
+
+	moves = p.castlingMoves(moves)
+	if p.rules.Has(Crazyhouse) {
+		moves = p.dropMoves(moves, ^occupied)
+	}
+	return moves
+}
+
+// dropMoves appends the drops of the pieces in the pocket of the side to
+// move onto the empty squares. Pawns cannot be dropped on the first or
+// last rank.
+func (p *Position) dropMoves(moves []Move, empty Bitboard) []Move {
+	pocket := &p.pockets[p.side]
+	for _, pieceType := range []board.PieceType{board.Pawn, board.Knight, board.Bishop, board.Rook, board.Queen} {
+		if pocket[pieceType] == 0 {
+			continue
+		}
+		targets := empty
+		if pieceType == board.Pawn {
+			targets &^= row0 | row7
+		}
+		for targets != 0 {
+			moves = append(moves, Move{From: NoSquare, To: targets.PopFirst(), Drop: pieceType})
+		}
+	}
+	return moves
+}
+
+// pawnMoves appends the pushes, captures and promotions of the side to move
+func (p *Position) pawnMoves(moves []Move, enemies, occupied Bitboard) []Move {
+	us := p.side
+	forward, startRow, lastRow := -8, 6, 0
+	if us == board.Black {
+		forward, startRow, lastRow = 8, 1, 7
+	}
+
+	targets := enemies
+	if p.epSquare != NoSquare {
+		targets |= p.epSquare.Bitboard()
+	}
+
+	promotions := promotionTypes
+	if p.rules.Has(Antichess) {
+		promotions = antichessPromotionTypes
+	}
+
+	pawns := p.Pieces(us, board.Pawn)
+	for pawns != 0 {
+		from := pawns.PopFirst()
+
+		var to Bitboard
+		push := from + Square(forward)
+		if !occupied.Has(push) {
+			to |= push.Bitboard()
+			double := push + Square(forward)
+			if (from.Row() == startRow || p.hordeFirstRank(from)) && !occupied.Has(double) {
+				to |= double.Bitboard()
+			}
+		}
+		to |= PawnAttacks(from, us) & targets
+
+		for to != 0 {
+			target := to.PopFirst()
+			if target.Row() != lastRow {
+				moves = append(moves, Move{From: from, To: target})
+				continue
+			}
+			for _, promotion := range promotions {
+				moves = append(moves, Move{From: from, To: target, Promotion: promotion})
+			}
+		}
+	}
+	return moves
+}
+
+// hordeFirstRank reports whether s is on White's first rank in Horde, where
+// White pawns may also move two squares
+func (p *Position) hordeFirstRank(s Square) bool {
+	return p.rules.Has(Horde) && row7.Has(s)
+}
+
+// castlingMoves appends the castling moves of the side to move. Every
+// square the king and rook pass over or land on must be empty apart from
+// the two of them, and the king may not start on or cross an attacked
+// square. The square the king lands on is checked with the other moves
+// of the king.
+func (p *Position) castlingMoves(moves []Move) []Move {
+	us := p.side
+	them := opponentOf(us)
+	king := p.King(us)
+	if king == NoSquare || p.castling&(KingSide(us)|QueenSide(us)) == 0 {
+		return moves
+	}
+
+	occupied := p.Occupied()
+	for _, right := range []CastlingRights{KingSide(us), QueenSide(us)} {
+		if p.castling&right == 0 {
+			continue
+		}
+		rook := p.CastlingRook(right)
+		kingTo, rookTo := CastlingTargets(right, us)
+
+		path := between(king, kingTo) | between(rook, rookTo)
+		if occupied&^(king.Bitboard()|rook.Bitboard())&path != 0 {
+			continue
+		}
+		crossed := between(king, kingTo) &^ kingTo.Bitboard()
+		if enemyKing := p.King(them); p.rules.Has(Atomic) && enemyKing != NoSquare {
+			// The enemy king cannot attack the squares next to it, so an
+			// attack may pass through the king's own square
+			crossed &^= KingAttacks(enemyKing)
+		}
+		if p.rules.Has(FogOfWar) {
+			// Without check the king may castle out of and through attacks
+			crossed = 0
+		}
+		safe := true
+		for crossed != 0 {
+			if p.Attackers(crossed.PopFirst(), them, occupied&^king.Bitboard()) != 0 {
+				safe = false
+				break
+			}
+		}
+		if !safe {
+			continue
+		}
+
+		if p.rules.Has(Chess960) {
+			moves = append(moves, Move{From: king, To: rook})
+		} else {
+			moves = append(moves, Move{From: king, To: kingTo})
+		}
+	}
+	return moves
+}
+
+// CastlingTargets returns where the king and rook end up when castling with
+// the given right
+func CastlingTargets(right CastlingRights, color board.Color) (king, rook Square) {
+	row := HomeRow(color)
+	if right == KingSide(color) {
+		return SquareAt(row, 6), SquareAt(row, 5)
+	}
+	return SquareAt(row, 2), SquareAt(row, 3)
+}
+
+// between returns the squares from a to b on the same row, both included
+func between(a, b Square) Bitboard {
+	if a > b {
+		a, b = b, a
+	}
+	return (b.Bitboard() - a.Bitboard()) | b.Bitboard()
+}
+
+// CastlingRight returns the castling right a move uses, or NoCastling if
+// it is not a castling move. It must be called before the move is made.
+func (p *Position) CastlingRight(m Move) CastlingRights {
+	if m.Drop != board.Empty {
+		return NoCastling
+	}
+	moving := p.Piece(m.From)
+	if moving.Type != board.King {
+		return NoCastling
+	}
+	if p.rules.Has(Chess960) {
+		if p.Piece(m.To) != (board.Piece{Type: board.Rook, Color: moving.Color}) {
+			return NoCastling
+		}
+	} else if m.To-m.From != 2 && m.From-m.To != 2 {
+		return NoCastling
+	}
+	if m.To > m.From {
+		return KingSide(moving.Color)
+	}
+	return QueenSide(moving.Color)
+}
+
+// LegalMoves returns the legal moves of the side to move
+func (p *Position) LegalMoves() []Move {
+	moves := p.PseudoLegalMoves(make([]Move, 0, 64))
+	filter := p.newLegalityFilter()
+	legal := moves[:0]
+	for _, m := range moves {
+		if filter.isLegal(m) {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+// HasLegalMoves reports whether the side to move has any legal move
+func (p *Position) HasLegalMoves() bool {
+	var buf [64]Move
+	filter := p.newLegalityFilter()
+	for _, m := range p.PseudoLegalMoves(buf[:0]) {
+		if filter.isLegal(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// legalityFilter checks pseudo-legal moves of one position. Only moves that
+// could expose the king are played out: king moves, en passant, moves out
+// of check and moves of pieces on a line with the king. In Atomic, where a
+// capture can blow up pieces anywhere, every move is played out, and in
+// Antichess every pseudo-legal move is legal. In Fog of War every
+// pseudo-legal move is legal until a king has been captured.
+type legalityFilter struct {
+	p       *Position
+	king    Square
+	inCheck bool
+	lines   Bitboard // Squares a pinned piece could stand on
+}
+
+func (p *Position) newLegalityFilter() legalityFilter {
+	f := legalityFilter{p: p, king: p.King(p.side)}
+	if f.king != NoSquare {
+		f.inCheck = p.IsAttacked(f.king, opponentOf(p.side))
+		f.lines = QueenAttacks(f.king, 0)
+	}
+	return f
+}
+
+func (f legalityFilter) isLegal(m Move) bool {
+	if f.p.rules.Has(Antichess) {
+		return true
+	}
+	if f.p.rules.Has(FogOfWar) {
+		return f.king != NoSquare && f.p.King(opponentOf(f.p.side)) != NoSquare
+	}
+	if f.p.rules.Has(Atomic) {
+		return f.p.IsLegal(m)
+	}
+	if m.Drop != board.Empty {
+		// A drop cannot uncover an attack, but it may fail to block one
+		return !f.inCheck || f.p.IsLegal(m)
+	}
+	if f.inCheck || m.From == f.king || f.lines.Has(m.From) ||
+		(m.To == f.p.epSquare && f.p.Piece(m.From).Type == board.Pawn) {
+		return f.p.IsLegal(m)
+	}
+	return true
+}
+
+// IsCapture reports whether a pseudo-legal move captures an enemy piece,
+// en passant included
+func (p *Position) IsCapture(m Move) bool {
+	if m.Drop != board.Empty {
+		return false
+	}
+	if p.Piece(m.To).Color == opponentOf(p.side) {
+		return true
+	}
+	return m.To == p.epSquare && p.Piece(m.From).Type == board.Pawn
+}
+
+// IsLegal reports whether a pseudo-legal move leaves the mover's king safe.
+// In Atomic the mover's king must also survive the move, and blowing up
+// the enemy king is legal even if it leaves the mover's king attacked.
+// Once a king is gone there are no legal moves.
+func (p *Position) IsLegal(m Move) bool {
+	us, them := p.side, opponentOf(p.side)
+	if p.rules.Has(Atomic) && (p.King(us) == NoSquare || p.King(them) == NoSquare) {
+		return false
+	}
+	undo := p.MakeMove(m)
+	legal := !p.InCheck(us)
+	if p.rules.Has(Atomic) {
+		legal = p.King(us) != NoSquare && (legal || p.King(them) == NoSquare)
+	}
+	p.UnmakeMove(m, undo)
+	return legal
+}
+
+// Undo holds what UnmakeMove needs to take back a move and cannot work out
+// from the position after it
+type Undo struct {
+	captured       board.Piece
+	castled        CastlingRights // The right used, if the move castled
+	gaveCheck      bool           // Whether the move added to the checks counted
+	promoted       Bitboard
+	exploded       Bitboard       // Squares blown up by an Atomic capture
+	explodedPieces [9]board.Piece // The pieces on them, in square order
+	castling       CastlingRights
+	epSquare       Square
+	halfMoveClock  int
+	hash           uint64
+}
+
+// MakeMove plays a pseudo-legal move, updating the pieces, pockets,
+// castling rights, en passant square, move clocks, side to move, checks
+// given and hash. In Atomic, captures set off explosions. Passing the move
+// and the returned Undo to UnmakeMove restores the position.
+func (p *Position) MakeMove(m Move) Undo {
+	us := p.side
+	var moving board.Piece
+	if m.Drop != board.Empty {
+		moving = board.Piece{Type: m.Drop, Color: us}
+	} else {
+		moving = p.Piece(m.From)
+	}
+	castled := p.CastlingRight(m)
+	captured := p.Piece(m.To)
+	if castled != NoCastling {
+		captured = board.Piece{}
+	}
+	undo := Undo{
+		captured:      captured,
+		castled:       castled,
+		promoted:      p.promoted,
+		castling:      p.castling,
+		epSquare:      p.epSquare,
+		halfMoveClock: p.halfMoveClock,
+		hash:          p.hash,
+	}
+
+	// Take the old castling rights and en passant file out of the hash
+	p.hash ^= zobristCastling[p.castling] ^ p.enPassantKey()
+
+	p.halfMoveClock++
+	if moving.Type == board.Pawn || captured.Type != board.Empty {
+		p.halfMoveClock = 0
+	}
+
+	if captured.Type != board.Empty {
+		p.capture(m.To)
+	}
+	if moving.Type == board.Pawn && m.Drop == board.Empty && m.To == p.epSquare {
+		// The captured pawn is beside the capturing one
+		s := SquareAt(m.From.Row(), m.To.Col())
+		undo.captured = p.Piece(s)
+		p.capture(s)
+	}
+
+	if m.Drop != board.Empty {
+		p.addToPocket(us, m.Drop, -1)
+		p.put(moving, m.To)
+	} else if castled != NoCastling {
+		// Lift both pieces first, as in Chess960 each may land where the
+		// other stood
+		rookFrom := p.CastlingRook(castled)
+		kingTo, rookTo := CastlingTargets(castled, us)
+		rook := p.Piece(rookFrom)
+		p.remove(m.From)
+		p.remove(rookFrom)
+		p.put(moving, kingTo)
+		p.put(rook, rookTo)
+	} else {
+		p.remove(m.From)
+		if m.Promotion != board.Empty {
+			moving.Type = m.Promotion
+		}
+		p.put(moving, m.To)
+		if p.rules.Has(Crazyhouse) && (m.Promotion != board.Empty || p.promoted.Has(m.From)) {
+			p.promoted = p.promoted&^m.From.Bitboard() | m.To.Bitboard()
+		}
+	}
+
+	if p.rules.Has(Atomic) && undo.captured.Type != board.Empty {
+		p.explode(m.To, &undo)
+	}
+
+	p.epSquare = NoSquare
+	if moving.Type == board.Pawn && m.Drop == board.Empty && (m.To-m.From == 16 || m.From-m.To == 16) && !p.hordeFirstRank(m.From) {
+		p.epSquare = (m.From + m.To) / 2
+	}
+
+	if moving.Type == board.King {
+		p.castling &^= KingSide(us) | QueenSide(us)
+	}
+	// Moving a rook from its starting square, or capturing or blowing it
+	// up there, ends castling with that rook
+	for rights := p.castling; rights != 0; rights &= rights - 1 {
+		right := rights & -rights
+		if rook := p.CastlingRook(right); rook == m.From || rook == m.To || undo.exploded.Has(rook) {
+			p.castling &^= right
+		}
+	}
+
+	p.side = opponentOf(us)
+	if us == board.Black {
+		p.fullMoveNumber++
+	}
+
+	if p.rules.Has(CountChecks) && p.InCheck(p.side) {
+		p.hash ^= checksKey(us, p.checks[us]) ^ checksKey(us, p.checks[us]+1)
+		p.checks[us]++
+		undo.gaveCheck = true
+	}
+
+	p.hash ^= zobristCastling[p.castling] ^ p.enPassantKey() ^ zobristBlack
+	return undo
+}
+
+// UnmakeMove takes back a move played with MakeMove, given the Undo it
+// returned. Moves must be taken back in the reverse order they were made.
+func (p *Position) UnmakeMove(m Move, undo Undo) {
+	us := opponentOf(p.side)
+	p.side = us
+	if us == board.Black {
+		p.fullMoveNumber--
+	}
+	if undo.gaveCheck {
+		p.checks[us]--
+	}
+
+	// Put back what an Atomic capture blew up, the capturing piece included
+	for i, blast := 0, undo.exploded; blast != 0; i++ {
+		p.put(undo.explodedPieces[i], blast.PopFirst())
+	}
+
+	var moving board.Piece
+	if m.Drop != board.Empty {
+		moving = p.Piece(m.To)
+		p.remove(m.To)
+		p.addToPocket(us, m.Drop, 1)
+	} else if undo.castled != NoCastling {
+		rookFrom := p.CastlingRook(undo.castled)
+		kingTo, rookTo := CastlingTargets(undo.castled, us)
+		moving = p.Piece(kingTo)
+		rook := p.Piece(rookTo)
+		p.remove(kingTo)
+		p.remove(rookTo)
+		p.put(moving, m.From)
+		p.put(rook, rookFrom)
+	} else {
+		moving = p.Piece(m.To)
+		p.remove(m.To)
+		if m.Promotion != board.Empty {
+			moving.Type = board.Pawn
+		}
+		p.put(moving, m.From)
+	}
+
+	if undo.captured.Type != board.Empty {
+		s := m.To
+		if moving.Type == board.Pawn && m.To == undo.epSquare {
+			s = SquareAt(m.From.Row(), m.To.Col())
+		}
+		p.put(undo.captured, s)
+		if p.rules.Has(Crazyhouse) && !p.rules.Has(Bughouse) {
+			p.addToPocket(us, pocketType(undo.captured, undo.promoted.Has(s)), -1)
+		}
+	}
+
+	p.promoted = undo.promoted
+	p.castling = undo.castling
+	p.epSquare = undo.epSquare
+	p.halfMoveClock = undo.halfMoveClock
+	p.hash = undo.hash
+}
+
+// capture takes the piece on s off the board. In Crazyhouse it goes to the
+// pocket of the side to move, but not in Bughouse.
+func (p *Position) capture(s Square) {
+	if p.rules.Has(Crazyhouse) {
+		if !p.rules.Has(Bughouse) {
+			p.addToPocket(p.side, pocketType(p.Piece(s), p.promoted.Has(s)), 1)
+		}
+		p.promoted &^= s.Bitboard()
+	}
+	p.remove(s)
+}
+
+// explode blows up the capturing piece on s and every piece but a pawn
+// next to it, noting them in undo for UnmakeMove. A king blown up loses
+// its castling rights.
+func (p *Position) explode(s Square, undo *Undo) {
+	blast := (KingAttacks(s)&^p.byType[board.Pawn] | s.Bitboard()) & p.Occupied()
+	undo.exploded = blast
+	for i := 0; blast != 0; i++ {
+		target := blast.PopFirst()
+		piece := p.Piece(target)
+		undo.explodedPieces[i] = piece
+		p.remove(target)
+		if piece.Type == board.King {
+			p.castling &^= KingSide(piece.Color) | QueenSide(piece.Color)
+		}
+	}
+}
+
+// pocketType returns the type a captured piece has in the capturer's
+// pocket: its own type, or pawn if it was promoted
+func pocketType(captured board.Piece, promoted bool) board.PieceType {
+	if promoted {
+		return board.Pawn
+	}
+	return captured.Type
+}
+
+// Perft counts the leaf nodes of the legal move tree to the given depth.
+// The moves are taken back, so the position is left as it was.
+func (p *Position) Perft(depth int) int {
+	if depth <= 0 {
+		return 1
+	}
+	moves := p.LegalMoves()
+	if depth == 1 {
+		return len(moves)
+	}
+
+	nodes := 0
+	for _, m := range moves {
+		undo := p.MakeMove(m)
+		nodes += p.Perft(depth - 1)
+		p.UnmakeMove(m, undo)
+	}
+	return nodes
+}
@@ -0,0 +1,263 @@
+package bitboard
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/user/chess/pkg/board"
+)
+
+func TestLegalMovesMatchFullCheck(t *testing.T) {
+	// LegalMoves only plays out moves that might expose the king; every
+	// other pseudo-legal move must really be legal
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 100; i++ {
+		randomGame(rng, 200, func(p *Position) {
+			var want []Move
+			for _, m := range p.PseudoLegalMoves(nil) {
+				if p.IsLegal(m) {
+					want = append(want, m)
+				}
+			}
+			got := p.LegalMoves()
+			if len(got) != len(want) {
+				t.Fatalf("LegalMoves() = %v, want %v", got, want)
+			}
+			for j := range got {
+				if got[j] != want[j] {
+					t.Fatalf("LegalMoves() = %v, want %v", got, want)
+				}
+			}
+			if p.HasLegalMoves() != (len(want) > 0) {
+				t.Fatalf("HasLegalMoves() = %v with %d legal moves", p.HasLegalMoves(), len(want))
+			}
+		})
+	}
+}
+
+func TestPerftStartPosition(t *testing.T) {
+	want := []int{20, 400, 8902, 197281, 4865609}
+	p := StartPosition()
+	for i, nodes := range want {
+		depth := i + 1
+		if testing.Short() && nodes > 10000 {
+			break
+		}
+		if got := p.Perft(depth); got != nodes {
+			t.Errorf("Perft(%d) = %d, want %d", depth, got, nodes)
+		}
+	}
+}
+
+func BenchmarkPerft5(b *testing.B) {
+	p := StartPosition()
+	for i := 0; i < b.N; i++ {
+		p.Perft(5)
+	}
+}
+
+func TestUnmakeMoveRestoresPosition(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for i := 0; i < 50; i++ {
+		randomGame(rng, 200, func(p *Position) {
+			before := *p
+			for _, m := range p.LegalMoves() {
+				undo := p.MakeMove(m)
+				p.UnmakeMove(m, undo)
+				if *p != before {
+					t.Fatalf("position differs after making and unmaking %v", m)
+				}
+			}
+		})
+	}
+}
+
+func TestCrazyhouseKeepsMaterial(t *testing.T) {
+	// Pieces only move between the board and the pockets, promoted pieces
+	// counting as pawns
+	want := [7]int{board.Pawn: 16, board.Knight: 4, board.Bishop: 4, board.Rook: 4, board.Queen: 2}
+
+	rng := rand.New(rand.NewSource(6))
+	for i := 0; i < 50; i++ {
+		p := StartPosition()
+		p.SetRules(p.Rules() | Crazyhouse)
+		for ply := 0; ply < 200; ply++ {
+			var got [7]int
+			for s := Square(0); s < 64; s++ {
+				piece := p.Piece(s)
+				if p.Promoted().Has(s) {
+					piece.Type = board.Pawn
+				}
+				got[piece.Type]++
+			}
+			for _, color := range []board.Color{board.White, board.Black} {
+				for pieceType, n := range p.Pocket(color) {
+					got[pieceType] += n
+				}
+			}
+			got[board.Empty], got[board.King] = 0, 0
+			if got != want {
+				t.Fatalf("material %v, want %v", got, want)
+			}
+			if p.Hash() != p.computeHash() {
+				t.Fatalf("incremental hash differs from recomputed hash")
+			}
+
+			moves := p.LegalMoves()
+			legal := 0
+			for _, m := range p.PseudoLegalMoves(nil) {
+				if p.IsLegal(m) {
+					legal++
+				}
+			}
+			if len(moves) != legal {
+				t.Fatalf("%d legal moves, want %d", len(moves), legal)
+			}
+			if len(moves) == 0 {
+				break
+			}
+			before := p
+			for _, m := range moves {
+				undo := p.MakeMove(m)
+				p.UnmakeMove(m, undo)
+				if p != before {
+					t.Fatalf("position differs after making and unmaking %v", m)
+				}
+			}
+			p.MakeMove(moves[rng.Intn(len(moves))])
+		}
+	}
+}
+
+func TestAtomicExplosion(t *testing.T) {
+	// Nxd7 blows up the knight, the rook, the bishops on c8 and e8 and the
+	// queen on d8, but not the pawns on c7 and e7
+	b := &board.Board{}
+	for name, piece := range map[string]board.Piece{
+		"a1": {Type: board.King, Color: board.White},
+		"b6": {Type: board.Knight, Color: board.White},
+		"h8": {Type: board.King, Color: board.Black},
+		"c7": {Type: board.Pawn, Color: board.Black},
+		"e7": {Type: board.Pawn, Color: board.Black},
+		"d7": {Type: board.Rook, Color: board.Black},
+		"c8": {Type: board.Bishop, Color: board.Black},
+		"e8": {Type: board.Bishop, Color: board.Black},
+		"d8": {Type: board.Queen, Color: board.Black},
+	} {
+		b.SetPiece(square(t, name).Position(), piece)
+	}
+	p := NewPosition(b, board.White)
+	p.SetRules(p.Rules() | Atomic)
+
+	before := p
+	m := Move{From: square(t, "b6"), To: square(t, "d7")}
+	undo := p.MakeMove(m)
+	if got := p.Occupied().Count(); got != 4 {
+		t.Errorf("%d pieces left after the explosion, want 4", got)
+	}
+	if p.Hash() != p.computeHash() {
+		t.Error("incremental hash differs from recomputed hash")
+	}
+	p.UnmakeMove(m, undo)
+	if p != before {
+		t.Error("position differs after making and unmaking the capture")
+	}
+}
+
+func TestAtomicKingsTouching(t *testing.T) {
+	// The rook attacks the white king, but not while the kings touch
+	b := &board.Board{}
+	b.SetPiece(square(t, "e4").Position(), board.Piece{Type: board.King, Color: board.White})
+	b.SetPiece(square(t, "e5").Position(), board.Piece{Type: board.King, Color: board.Black})
+	b.SetPiece(square(t, "a4").Position(), board.Piece{Type: board.Rook, Color: board.Black})
+	p := NewPosition(b, board.White)
+	if !p.InCheck(board.White) {
+		t.Error("InCheck(White) = false in standard chess")
+	}
+	p.SetRules(p.Rules() | Atomic)
+	if p.InCheck(board.White) {
+		t.Error("InCheck(White) = true with the kings touching")
+	}
+	// The king cannot take the rook, as it would blow itself up
+	for _, m := range p.LegalMoves() {
+		if p.Piece(m.To).Type != board.Empty {
+			t.Errorf("LegalMoves() has the capture %v", m)
+		}
+	}
+}
+
+func TestAtomicMakeUnmake(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 50; i++ {
+		p := StartPosition()
+		p.SetRules(p.Rules() | Atomic)
+		for ply := 0; ply < 200; ply++ {
+			if p.Hash() != p.computeHash() {
+				t.Fatalf("incremental hash differs from recomputed hash")
+			}
+			moves := p.LegalMoves()
+			if len(moves) == 0 {
+				break
+			}
+			before := p
+			for _, m := range moves {
+				undo := p.MakeMove(m)
+				if p.King(before.SideToMove()) == NoSquare {
+					t.Fatalf("legal move %v blew up the mover's king", m)
+				}
+				p.UnmakeMove(m, undo)
+				if p != before {
+					t.Fatalf("position differs after making and unmaking %v", m)
+				}
+			}
+			p.MakeMove(moves[rng.Intn(len(moves))])
+		}
+	}
+}
+
+func TestAntichessMoves(t *testing.T) {
+	// The king may walk into the rook's line, and the pawn may promote to
+	// a king
+	b := &board.Board{}
+	b.SetPiece(square(t, "a1").Position(), board.Piece{Type: board.King, Color: board.White})
+	b.SetPiece(square(t, "e7").Position(), board.Piece{Type: board.Pawn, Color: board.White})
+	b.SetPiece(square(t, "h2").Position(), board.Piece{Type: board.Rook, Color: board.Black})
+	p := NewPosition(b, board.White)
+	if got := len(p.LegalMoves()); got != 5 {
+		t.Errorf("%d legal moves in standard chess, want 5", got)
+	}
+	p.SetRules(p.Rules() | Antichess)
+	if got := len(p.LegalMoves()); got != 8 {
+		t.Errorf("%d legal moves in Antichess, want 8", got)
+	}
+	if p.InCheck(board.White) {
+		t.Error("InCheck(White) = true in Antichess")
+	}
+}
+
+func TestHordeMoves(t *testing.T) {
+	// A pawn on the first rank may move two squares in Horde, without
+	// leaving an en passant square behind
+	b := &board.Board{}
+	b.SetPiece(square(t, "e8").Position(), board.Piece{Type: board.King, Color: board.Black})
+	b.SetPiece(square(t, "c1").Position(), board.Piece{Type: board.Pawn, Color: board.White})
+	b.SetPiece(square(t, "d3").Position(), board.Piece{Type: board.Pawn, Color: board.Black})
+	p := NewPosition(b, board.White)
+	if got := len(p.LegalMoves()); got != 1 {
+		t.Errorf("%d legal moves without Horde rules, want 1", got)
+	}
+	p.SetRules(p.Rules() | Horde)
+	if got := len(p.LegalMoves()); got != 2 {
+		t.Errorf("%d legal moves in Horde, want 2", got)
+	}
+
+	m := Move{From: square(t, "c1"), To: square(t, "c3")}
+	undo := p.MakeMove(m)
+	if p.EnPassant() != NoSquare {
+		t.Errorf("EnPassant() = %v after %v, want none", p.EnPassant(), m)
+	}
+	if got := len(p.LegalMoves()); got != 6 {
+		t.Errorf("%d legal moves after %v, want 6", got, m)
+	}
+	p.UnmakeMove(m, undo)
+}
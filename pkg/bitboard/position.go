@@ -0,0 +1,320 @@
+package bitboard
+
+import (
+	"math/bits"
+
+	"github.com/user/chess/pkg/board"
+)
+
+// CastlingRights is a set of castling rights
+type CastlingRights uint8
+
+const (
+	WhiteKingSide CastlingRights = 1 << iota
+	WhiteQueenSide
+	BlackKingSide
+	BlackQueenSide
+
+	NoCastling  CastlingRights = 0
+	AllCastling                = WhiteKingSide | WhiteQueenSide | BlackKingSide | BlackQueenSide
+)
+
+// KingSide returns the kingside castling right of a color
+func KingSide(color board.Color) CastlingRights {
+	if color == board.White {
+		return WhiteKingSide
+	}
+	return BlackKingSide
+}
+
+// QueenSide returns the queenside castling right of a color
+func QueenSide(color board.Color) CastlingRights {
+	if color == board.White {
+		return WhiteQueenSide
+	}
+	return BlackQueenSide
+}
+
+// index returns the position of a single castling right in the rook table
+func (r CastlingRights) index() int {
+	return bits.TrailingZeros8(uint8(r))
+}
+
+// HomeRow returns the row a color's pieces start on
+func HomeRow(color board.Color) int {
+	if color == board.White {
+		return 7
+	}
+	return 0
+}
+
+// Position is a chess position: the pieces, the side to move, castling
+// rights, en passant square and move clocks. It is a plain value, so
+// copying it gives an independent position. Methods that try moves, such
+// as LegalMoves and Perft, make and take them back on the position itself;
+// give each goroutine its own copy.
+type Position struct {
+	byType         [7]Bitboard // Indexed by piece type, both colors
+	byColor        [3]Bitboard // Indexed by color, all piece types
+	squares        [64]uint8   // The piece on each square, see pieceCode
+	side           board.Color
+	castling       CastlingRights
+	castlingRooks  [4]Square // The rook of each castling right, see CastlingRights.index
+	rules          Rules
+	checks         [3]int          // Checks given by each color, when counted
+	pockets        [3]board.Pocket // Pieces in hand, indexed by color
+	promoted       Bitboard        // Pieces that were pawns, in Crazyhouse
+	epSquare       Square
+	halfMoveClock  int
+	fullMoveNumber int
+	hash           uint64
+}
+
+// pieceCode packs a piece into a byte for the square lookup table
+func pieceCode(p board.Piece) uint8 {
+	return uint8(p.Color)<<3 | uint8(p.Type)
+}
+
+// NewPosition returns a position with the pieces of b and the given side to
+// move. It has no castling rights or en passant square, a half-move clock
+// of 0 and a full-move number of 1. Castling rights use the rooks in the
+// corners until SetCastlingRook says otherwise.
+func NewPosition(b *board.Board, side board.Color) Position {
+	p := Position{
+		side:           side,
+		epSquare:       NoSquare,
+		fullMoveNumber: 1,
+	}
+	for _, color := range []board.Color{board.White, board.Black} {
+		row := HomeRow(color)
+		p.castlingRooks[KingSide(color).index()] = SquareAt(row, 7)
+		p.castlingRooks[QueenSide(color).index()] = SquareAt(row, 0)
+	}
+	for s := Square(0); s < 64; s++ {
+		if piece := b.GetPiece(s.Position()); piece.Type != board.Empty {
+			p.put(piece, s)
+		}
+	}
+	p.hash = p.computeHash()
+	return p
+}
+
+// StartPosition returns the standard starting position
+func StartPosition() Position {
+	p := NewPosition(board.NewBoard(), board.White)
+	p.SetCastling(AllCastling)
+	return p
+}
+
+// SetChecks sets the number of checks a color has given
+func (p *Position) SetChecks(color board.Color, n int) {
+	p.checks[color] = n
+	p.hash = p.computeHash()
+}
+
+// Checks returns the number of checks a color has given, if counted
+func (p *Position) Checks(color board.Color) int {
+	return p.checks[color]
+}
+
+// SetPocket sets the pieces a color holds in hand
+func (p *Position) SetPocket(color board.Color, pocket board.Pocket) {
+	p.pockets[color] = pocket
+	p.hash = p.computeHash()
+}
+
+// Pocket returns the pieces a color holds in hand
+func (p *Position) Pocket(color board.Color) board.Pocket {
+	return p.pockets[color]
+}
+
+// SetPromoted sets the squares of the pieces that were promoted from pawns
+func (p *Position) SetPromoted(promoted Bitboard) {
+	p.promoted = promoted
+}
+
+// Promoted returns the squares of the pieces that were promoted from pawns.
+// Only Crazyhouse keeps track of them.
+func (p *Position) Promoted() Bitboard {
+	return p.promoted
+}
+
+// addToPocket adds n pieces of a type to a color's pocket, or takes them
+// out if n is negative
+func (p *Position) addToPocket(color board.Color, pieceType board.PieceType, n int) {
+	count := &p.pockets[color][pieceType]
+	p.hash ^= pocketKey(color, pieceType, *count)
+	*count += n
+	p.hash ^= pocketKey(color, pieceType, *count)
+}
+
+// SetCastlingRook sets the square of the rook a single castling right
+// castles with. Call SetCastling afterwards to check the rights again.
+func (p *Position) SetCastlingRook(right CastlingRights, s Square) {
+	p.castlingRooks[right.index()] = s
+}
+
+// CastlingRook returns the square of the rook a single castling right
+// castles with
+func (p *Position) CastlingRook(right CastlingRights) Square {
+	return p.castlingRooks[right.index()]
+}
+
+// SetCastling sets the castling rights. Rights are dropped when the king
+// is not on its home row, or not on the e-file outside Chess960, or when
+// the rook is not on its square on the correct side of the king.
+func (p *Position) SetCastling(rights CastlingRights) {
+	for _, color := range []board.Color{board.White, board.Black} {
+		king := p.King(color)
+		if king == NoSquare || king.Row() != HomeRow(color) || (!p.rules.Has(Chess960) && king.Col() != 4) {
+			rights &^= KingSide(color) | QueenSide(color)
+			continue
+		}
+		rook := board.Piece{Type: board.Rook, Color: color}
+		if s := p.CastlingRook(KingSide(color)); s == NoSquare || p.Piece(s) != rook || s <= king {
+			rights &^= KingSide(color)
+		}
+		if s := p.CastlingRook(QueenSide(color)); s == NoSquare || p.Piece(s) != rook || s >= king {
+			rights &^= QueenSide(color)
+		}
+	}
+	p.castling = rights
+	p.hash = p.computeHash()
+}
+
+// SetEnPassant sets the square a pawn may capture en passant, or NoSquare
+func (p *Position) SetEnPassant(s Square) {
+	p.epSquare = s
+	p.hash = p.computeHash()
+}
+
+// SetClocks sets the half-move clock and full-move number
+func (p *Position) SetClocks(halfMoveClock, fullMoveNumber int) {
+	p.halfMoveClock = halfMoveClock
+	p.fullMoveNumber = fullMoveNumber
+}
+
+// Board returns the pieces of the position as a board
+func (p *Position) Board() *board.Board {
+	b := &board.Board{}
+	occupied := p.Occupied()
+	for occupied != 0 {
+		s := occupied.PopFirst()
+		b.SetPiece(s.Position(), p.Piece(s))
+	}
+	return b
+}
+
+// Piece returns the piece on a square
+func (p *Position) Piece(s Square) board.Piece {
+	code := p.squares[s]
+	return board.Piece{Type: board.PieceType(code & 7), Color: board.Color(code >> 3)}
+}
+
+// Pieces returns the squares of the pieces of the given color and type
+func (p *Position) Pieces(color board.Color, pieceType board.PieceType) Bitboard {
+	return p.byColor[color] & p.byType[pieceType]
+}
+
+// Color returns the squares of all pieces of a color
+func (p *Position) Color(color board.Color) Bitboard {
+	return p.byColor[color]
+}
+
+// Occupied returns the squares holding a piece
+func (p *Position) Occupied() Bitboard {
+	return p.byColor[board.White] | p.byColor[board.Black]
+}
+
+// King returns the square of a color's king, or NoSquare if it has none
+func (p *Position) King(color board.Color) Square {
+	return p.Pieces(color, board.King).First()
+}
+
+// SideToMove returns the color of the player to move
+func (p *Position) SideToMove() board.Color {
+	return p.side
+}
+
+// Castling returns the castling rights
+func (p *Position) Castling() CastlingRights {
+	return p.castling
+}
+
+// EnPassant returns the en passant target square, or NoSquare
+func (p *Position) EnPassant() Square {
+	return p.epSquare
+}
+
+// HalfMoveClock returns the number of half-moves since the last capture or
+// pawn move
+func (p *Position) HalfMoveClock() int {
+	return p.halfMoveClock
+}
+
+// FullMoveNumber returns the number of the current move, starting at 1 and
+// increasing after each black move
+func (p *Position) FullMoveNumber() int {
+	return p.fullMoveNumber
+}
+
+// Hash returns the Zobrist hash of the position. Positions that are the
+// same for the repetition rules have the same hash.
+func (p *Position) Hash() uint64 {
+	return p.hash
+}
+
+// Attackers returns the pieces of the given color that attack s when the
+// given squares are occupied
+func (p *Position) Attackers(s Square, by board.Color, occupied Bitboard) Bitboard {
+	rooks := p.byType[board.Rook] | p.byType[board.Queen]
+	bishops := p.byType[board.Bishop] | p.byType[board.Queen]
+	attackers := PawnAttacks(s, opponentOf(by))&p.byType[board.Pawn] |
+		KnightAttacks(s)&p.byType[board.Knight] |
+		KingAttacks(s)&p.byType[board.King] |
+		RookAttacks(s, occupied)&rooks |
+		BishopAttacks(s, occupied)&bishops
+	return attackers & p.byColor[by]
+}
+
+// IsAttacked reports whether any piece of the given color attacks s
+func (p *Position) IsAttacked(s Square, by board.Color) bool {
+	return p.Attackers(s, by, p.Occupied()) != 0
+}
+
+// InCheck reports whether the king of the given color is attacked. In
+// Atomic, kings next to each other are never in check, as taking one would
+// blow up the other, and in Antichess and Fog of War there is no check at
+// all.
+func (p *Position) InCheck(color board.Color) bool {
+	king := p.King(color)
+	if king == NoSquare || p.rules.Has(Antichess) || p.rules.Has(FogOfWar) || (p.rules.Has(Atomic) && p.kingsTouch()) {
+		return false
+	}
+	return p.IsAttacked(king, opponentOf(color))
+}
+
+// kingsTouch reports whether the two kings stand next to each other
+func (p *Position) kingsTouch() bool {
+	king := p.King(board.White)
+	return king != NoSquare && KingAttacks(king)&p.Pieces(board.Black, board.King) != 0
+}
+
+// put places a piece on an empty square
+func (p *Position) put(piece board.Piece, s Square) {
+	bit := s.Bitboard()
+	p.byType[piece.Type] |= bit
+	p.byColor[piece.Color] |= bit
+	p.squares[s] = pieceCode(piece)
+	p.hash ^= pieceKey(piece, s)
+}
+
+// remove takes the piece off a square
+func (p *Position) remove(s Square) {
+	piece := p.Piece(s)
+	bit := s.Bitboard()
+	p.byType[piece.Type] &^= bit
+	p.byColor[piece.Color] &^= bit
+	p.squares[s] = 0
+	p.hash ^= pieceKey(piece, s)
+}
@@ -0,0 +1,109 @@
+package bitboard
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/user/chess/pkg/board"
+)
+
+// square parses a square in algebraic notation
+func square(t *testing.T, algebraic string) Square {
+	t.Helper()
+	pos, err := board.NewPosition(algebraic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return SquareOf(pos)
+}
+
+// randomGame plays random legal moves from the starting position and calls
+// visit with every position reached, including the first
+func randomGame(rng *rand.Rand, plies int, visit func(p *Position)) {
+	p := StartPosition()
+	for i := 0; i < plies; i++ {
+		visit(&p)
+		moves := p.LegalMoves()
+		if len(moves) == 0 {
+			return
+		}
+		p.MakeMove(moves[rng.Intn(len(moves))])
+	}
+	visit(&p)
+}
+
+func TestBoardRoundTrip(t *testing.T) {
+	b := board.NewBoard()
+	p := NewPosition(b, board.White)
+	if got := p.Board(); *got != *b {
+		t.Errorf("Board() differs from the board the position was made from")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	randomGame(rng, 100, func(p *Position) {
+		again := NewPosition(p.Board(), p.SideToMove())
+		if again.byType != p.byType || again.byColor != p.byColor || again.squares != p.squares {
+			t.Fatalf("pieces differ after converting to a board and back")
+		}
+	})
+}
+
+func TestHashMatchesRecomputed(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 50; i++ {
+		randomGame(rng, 150, func(p *Position) {
+			if p.Hash() != p.computeHash() {
+				t.Fatalf("incremental hash differs from recomputed hash")
+			}
+		})
+	}
+}
+
+func TestSetCastlingDropsUnavailableRights(t *testing.T) {
+	b := board.NewBoard()
+	b.SetPiece(square(t, "h1").Position(), board.Piece{})
+	b.SetPiece(square(t, "e8").Position(), board.Piece{})
+	b.SetPiece(square(t, "d8").Position(), board.Piece{Type: board.King, Color: board.Black})
+
+	p := NewPosition(b, board.White)
+	p.SetCastling(AllCastling)
+	if got := p.Castling(); got != WhiteQueenSide {
+		t.Errorf("Castling() = %b, want only white queenside", got)
+	}
+}
+
+func TestCountChecks(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 50; i++ {
+		p := StartPosition()
+		p.SetRules(p.Rules() | CountChecks)
+		var want [3]int
+		for ply := 0; ply < 150; ply++ {
+			moves := p.LegalMoves()
+			if len(moves) == 0 {
+				break
+			}
+			m := moves[rng.Intn(len(moves))]
+			before := p
+			undo := p.MakeMove(m)
+			after := p
+			p.UnmakeMove(m, undo)
+			if p != before {
+				t.Fatalf("position differs after making and unmaking %v", m)
+			}
+			p = after
+
+			if p.InCheck(p.SideToMove()) {
+				want[opponentOf(p.SideToMove())]++
+			}
+			for _, color := range []board.Color{board.White, board.Black} {
+				if got := p.Checks(color); got != want[color] {
+					t.Fatalf("Checks(%v) = %d, want %d", color, got, want[color])
+				}
+			}
+			if p.Hash() != p.computeHash() {
+				t.Fatalf("incremental hash differs from recomputed hash")
+			}
+		}
+	}
+}
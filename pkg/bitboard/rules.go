@@ -0,0 +1,66 @@
+package bitboard
+
+// Rules is a set of changes to how pieces move, capture and give check,
+// which the move generator and MakeMove follow. A variant that only adds
+// ways to win, restricts moves or changes the starting position needs
+// none; one that changes how the pieces themselves behave sets its rules
+// on every position it creates. A variant that needs the move generator to
+// do something new adds a rule here, documented like the others, rather
+// than a field of its own on Position.
+type Rules uint16
+
+const (
+	// Chess960 lets the king and rooks start anywhere on the home row,
+	// with the king between the rooks. Castling is written as the king
+	// capturing its own rook.
+	Chess960 Rules = 1 << iota
+
+	// CountChecks counts the checks each side gives, as Three-check
+	// needs. Counting starts from the counts set on the position.
+	CountChecks
+
+	// Crazyhouse sends captured pieces to the capturer's pocket, promoted
+	// pieces going back to pawns, and pieces in a pocket can be dropped on
+	// any empty square.
+	Crazyhouse
+
+	// Bughouse, on top of Crazyhouse, takes captured pieces off the board
+	// without putting them in the capturer's pocket: the game passes them
+	// on to the partner's board.
+	Bughouse
+
+	// Atomic makes a capture blow up the capturing piece and every piece
+	// but a pawn next to the capture square. A king next to the enemy king
+	// cannot be in check.
+	Atomic
+
+	// Antichess makes kings ordinary pieces that can be captured and are
+	// never in check, and pawns may promote to them.
+	Antichess
+
+	// Horde lets White pawns on the first rank move two squares, as from
+	// the second, but they cannot be taken en passant after doing so.
+	Horde
+
+	// FogOfWar removes check: kings may move into attacks and castle out
+	// of or through them, and a king can be captured.
+	FogOfWar
+
+	// StandardRules are the rules of standard chess
+	StandardRules Rules = 0
+)
+
+// Has reports whether all of the given rules are in the set
+func (r Rules) Has(rules Rules) bool {
+	return r&rules == rules
+}
+
+// SetRules sets the rules the position is played by
+func (p *Position) SetRules(rules Rules) {
+	p.rules = rules
+}
+
+// Rules returns the rules the position is played by
+func (p *Position) Rules() Rules {
+	return p.rules
+}
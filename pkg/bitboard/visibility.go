@@ -0,0 +1,32 @@
+package bitboard
+
+import (
+	"github.com/user/chess/pkg/board"
+)
+
+// Visible returns the squares the player of the given color can see in Fog
+// of War: the squares of their own pieces, the squares those pieces can
+// move to and the squares their pawns attack. A pawn that can be taken en
+// passant is seen as well. The moves are the pseudo-legal ones, which are
+// the legal ones under Fog of War rules.
+func (p *Position) Visible(color board.Color) Bitboard {
+	q := *p
+	if q.side != color {
+		// Only the side to move may capture en passant
+		q.side = color
+		q.epSquare = NoSquare
+	}
+
+	visible := q.Color(color)
+	var buf [256]Move
+	for _, m := range q.PseudoLegalMoves(buf[:0]) {
+		visible |= m.To.Bitboard()
+		if m.To == q.epSquare && q.Piece(m.From).Type == board.Pawn {
+			visible |= SquareAt(m.From.Row(), m.To.Col()).Bitboard()
+		}
+	}
+	for pawns := q.Pieces(color, board.Pawn); pawns != 0; {
+		visible |= PawnAttacks(pawns.PopFirst(), color)
+	}
+	return visible
+}
@@ -0,0 +1,83 @@
+package bitboard
+
+import (
+	"testing"
+
+	"github.com/user/chess/pkg/board"
+)
+
+func TestVisible(t *testing.T) {
+	p := StartPosition()
+	p.SetRules(p.Rules() | FogOfWar)
+	// Each side sees its own half of the board: its pieces and the two
+	// ranks its pawns and knights can reach
+	if got, want := p.Visible(board.White), row7|row7>>8|row7>>16|row7>>24; got != want {
+		t.Errorf("Visible(White) = %#x, want %#x", uint64(got), uint64(want))
+	}
+	if got, want := p.Visible(board.Black), row0|row0<<8|row0<<16|row0<<24; got != want {
+		t.Errorf("Visible(Black) = %#x, want %#x", uint64(got), uint64(want))
+	}
+
+	m := Move{From: square(t, "e2"), To: square(t, "e4")}
+	p.MakeMove(m)
+	visible := p.Visible(board.White)
+	for _, s := range []string{"e5", "d5", "f5", "a6", "h5", "e2"} {
+		if !visible.Has(square(t, s)) {
+			t.Errorf("White cannot see %s after %v", s, m)
+		}
+	}
+	for _, s := range []string{"a5", "e6", "d7"} {
+		if visible.Has(square(t, s)) {
+			t.Errorf("White sees %s after %v", s, m)
+		}
+	}
+}
+
+func TestVisibleEnPassant(t *testing.T) {
+	// The pawn on d5 can be taken en passant, so White sees it even though
+	// it stands on a square the pawn on e5 cannot move to
+	b := &board.Board{}
+	b.SetPiece(square(t, "e1").Position(), board.Piece{Type: board.King, Color: board.White})
+	b.SetPiece(square(t, "e8").Position(), board.Piece{Type: board.King, Color: board.Black})
+	b.SetPiece(square(t, "e5").Position(), board.Piece{Type: board.Pawn, Color: board.White})
+	b.SetPiece(square(t, "d5").Position(), board.Piece{Type: board.Pawn, Color: board.Black})
+	p := NewPosition(b, board.White)
+	p.SetRules(p.Rules() | FogOfWar)
+	if p.Visible(board.White).Has(square(t, "d5")) {
+		t.Error("White sees d5 without an en passant capture")
+	}
+	p.SetEnPassant(square(t, "d6"))
+	if !p.Visible(board.White).Has(square(t, "d5")) {
+		t.Error("White cannot see the pawn it can take en passant")
+	}
+}
+
+func TestFogOfWarMoves(t *testing.T) {
+	// The king may stay in the rook's line, walk into it or castle through
+	// it, and the rook may take the king
+	b := &board.Board{}
+	b.SetPiece(square(t, "e1").Position(), board.Piece{Type: board.King, Color: board.White})
+	b.SetPiece(square(t, "h1").Position(), board.Piece{Type: board.Rook, Color: board.White})
+	b.SetPiece(square(t, "e8").Position(), board.Piece{Type: board.King, Color: board.Black})
+	b.SetPiece(square(t, "f7").Position(), board.Piece{Type: board.Rook, Color: board.Black})
+	p := NewPosition(b, board.White)
+	p.SetCastling(WhiteKingSide)
+	if got := len(p.LegalMoves()); got != 12 {
+		t.Errorf("%d legal moves in standard chess, want 12", got)
+	}
+	p.SetRules(p.Rules() | FogOfWar)
+	if got := len(p.LegalMoves()); got != 15 {
+		t.Errorf("%d legal moves in Fog of War, want 15", got)
+	}
+	if p.InCheck(board.White) {
+		t.Error("InCheck(White) = true in Fog of War")
+	}
+
+	m := Move{From: square(t, "e1"), To: square(t, "f1")}
+	p.MakeMove(m)
+	m = Move{From: square(t, "f7"), To: square(t, "f1")}
+	p.MakeMove(m)
+	if got := p.LegalMoves(); len(got) != 0 {
+		t.Errorf("LegalMoves() = %v with the White king captured, want none", got)
+	}
+}
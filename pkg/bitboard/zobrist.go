@@ -0,0 +1,113 @@
+package bitboard
+
+import (
+	"math/rand"
+
+	"github.com/user/chess/pkg/board"
+)
+
+// Zobrist keys. Every piece on every square, each castling right, each en
+// passant file and the side to move has a random key; the hash of a
+// position is the XOR of the keys of everything in it. The keys come from
+// a fixed seed so hashes are stable between runs and can be stored.
+var (
+	zobristPieces    [3][7][64]uint64 // Indexed by color, piece type and square
+	zobristCastling  [16]uint64       // Indexed by the set of castling rights
+	zobristEnPassant [8]uint64        // Indexed by file
+	zobristBlack     uint64           // Black to move
+	zobristChecks    [3][4]uint64     // Indexed by color and checks given, none being 0
+	zobristPockets   [3][7][17]uint64 // Indexed by color, piece type and count, none being 0
+)
+
+func init() {
+	rng := rand.New(rand.NewSource(0x5eed))
+	for color := range zobristPieces {
+		for pieceType := range zobristPieces[color] {
+			for s := range zobristPieces[color][pieceType] {
+				zobristPieces[color][pieceType][s] = rng.Uint64()
+			}
+		}
+	}
+	var rightKeys [4]uint64
+	for i := range rightKeys {
+		rightKeys[i] = rng.Uint64()
+	}
+	for rights := range zobristCastling {
+		for i, key := range rightKeys {
+			if rights&(1<<uint(i)) != 0 {
+				zobristCastling[rights] ^= key
+			}
+		}
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = rng.Uint64()
+	}
+	zobristBlack = rng.Uint64()
+	for color := range zobristChecks {
+		for n := 1; n < len(zobristChecks[color]); n++ {
+			zobristChecks[color][n] = rng.Uint64()
+		}
+	}
+	for color := range zobristPockets {
+		for pieceType := range zobristPockets[color] {
+			for n := 1; n < len(zobristPockets[color][pieceType]); n++ {
+				zobristPockets[color][pieceType][n] = rng.Uint64()
+			}
+		}
+	}
+}
+
+// pieceKey returns the key of a piece on a square
+func pieceKey(p board.Piece, s Square) uint64 {
+	return zobristPieces[p.Color][p.Type][s]
+}
+
+// checksKey returns the key of the checks a color has given. More than
+// three checks share a key, as Three-check ends at three.
+func checksKey(color board.Color, n int) uint64 {
+	if n >= len(zobristChecks[color]) {
+		n = len(zobristChecks[color]) - 1
+	}
+	return zobristChecks[color][n]
+}
+
+// pocketKey returns the key of a color holding n pieces of a type in hand.
+// No color can hold more than 16 pieces of one type.
+func pocketKey(color board.Color, pieceType board.PieceType, n int) uint64 {
+	return zobristPockets[color][pieceType][n]
+}
+
+// enPassantKey returns the key of the en passant file. The file only counts
+// when a pawn of the side to move can capture en passant, since otherwise
+// the position is the same as without the en passant square.
+func (p *Position) enPassantKey() uint64 {
+	if p.epSquare == NoSquare {
+		return 0
+	}
+	// The capturing pawns stand where an enemy pawn on the target would attack
+	if PawnAttacks(p.epSquare, opponentOf(p.side))&p.Pieces(p.side, board.Pawn) == 0 {
+		return 0
+	}
+	return zobristEnPassant[p.epSquare.Col()]
+}
+
+// computeHash calculates the Zobrist hash of the position from scratch
+func (p *Position) computeHash() uint64 {
+	var h uint64
+	occupied := p.Occupied()
+	for occupied != 0 {
+		s := occupied.PopFirst()
+		h ^= pieceKey(p.Piece(s), s)
+	}
+	h ^= zobristCastling[p.castling] ^ p.enPassantKey()
+	h ^= checksKey(board.White, p.checks[board.White]) ^ checksKey(board.Black, p.checks[board.Black])
+	for _, color := range []board.Color{board.White, board.Black} {
+		for pieceType, n := range p.pockets[color] {
+			h ^= pocketKey(color, board.PieceType(pieceType), n)
+		}
+	}
+	if p.side == board.Black {
+		h ^= zobristBlack
+	}
+	return h
+}
@@ -68,6 +68,32 @@ func (p Piece) ASCIIString() string {
 	return symbol
 }
 
+// ParsePiece returns the piece for an ASCII symbol as produced by ASCIIString.
+// Uppercase letters are white pieces and lowercase letters are black pieces.
+func ParsePiece(symbol rune) (Piece, error) {
+	types := map[rune]PieceType{
+		'p': Pawn,
+		'n': Knight,
+		'b': Bishop,
+		'r': Rook,
+		'q': Queen,
+		'k': King,
+	}
+
+	color := Black
+	lower := symbol
+	if symbol >= 'A' && symbol <= 'Z' {
+		color = White
+		lower += 'a' - 'A'
+	}
+
+	pieceType, ok := types[lower]
+	if !ok {
+		return Piece{}, fmt.Errorf("invalid piece symbol: %c", symbol)
+	}
+	return Piece{Type: pieceType, Color: color}, nil
+}
+
 // Position represents a position on the chess board
 type Position struct {
 	Row int // 0-7
@@ -100,6 +126,113 @@ type Board struct {
 
 // NewBoard creates a new chess board with pieces in the initial position
 func NewBoard() *Board {
+	return newBoardWithBackRank([8]PieceType{Rook, Knight, Bishop, Queen, King, Bishop, Knight, Rook})
+}
+
+// Chess960Positions is the number of Chess960 starting positions
+const Chess960Positions = 960
+
+// NewChess960Board creates a board with the pieces in Chess960 starting
+// position n, numbered from 0 to 959 as in Scharnagl's scheme. Position
+// 518 is the standard starting position. It panics if n is out of range.
+func NewChess960Board(n int) *Board {
+	if n < 0 || n >= Chess960Positions {
+		panic(fmt.Sprintf("board: Chess960 position %d out of range", n))
+	}
+
+	var backRank [8]PieceType
+	// place puts a piece on the i-th empty square of the back rank,
+	// counting from the a-file
+	place := func(pieceType PieceType, i int) {
+		for col := range backRank {
+			if backRank[col] != Empty {
+				continue
+			}
+			if i == 0 {
+				backRank[col] = pieceType
+				return
+			}
+			i--
+		}
+	}
+
+	// The bishops go on opposite colors: one on the b, d, f or h-file and
+	// one on the a, c, e or g-file
+	backRank[n%4*2+1] = Bishop
+	n /= 4
+	backRank[n%4*2] = Bishop
+	n /= 4
+	place(Queen, n%6)
+	n /= 6
+
+	// The two knights take two of the five squares left
+	knights := [10][2]int{{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}}
+	place(Knight, knights[n][1])
+	place(Knight, knights[n][0])
+
+	// The king goes between the rooks on the three squares left
+	place(Rook, 0)
+	place(King, 0)
+	place(Rook, 0)
+
+	return newBoardWithBackRank(backRank)
+}
+
+// NewHordeBoard creates a board with the Horde starting position: Black's
+// usual army against 36 White pawns on the first four ranks and on b5, c5,
+// f5 and g5
+func NewHordeBoard() *Board {
+	return newBoardFromRanks([8]string{
+		"rnbqkbnr",
+		"pppppppp",
+		"........",
+		".PP..PP.",
+		"PPPPPPPP",
+		"PPPPPPPP",
+		"PPPPPPPP",
+		"PPPPPPPP",
+	})
+}
+
+// NewRacingKingsBoard creates a board with the Racing Kings starting
+// position, where both sides line up their pieces without pawns on the
+// first two ranks, Black on the left and White on the right
+func NewRacingKingsBoard() *Board {
+	return newBoardFromRanks([8]string{
+		"........",
+		"........",
+		"........",
+		"........",
+		"........",
+		"........",
+		"krbnNBRK",
+		"qrbnNBRQ",
+	})
+}
+
+// newBoardFromRanks creates a board from the pieces on each rank, eighth
+// rank first, written as in FEN with a dot for an empty square. It panics
+// on an invalid symbol.
+func newBoardFromRanks(ranks [8]string) *Board {
+	board := &Board{}
+	for row, rank := range ranks {
+		for col, symbol := range rank {
+			if symbol == '.' {
+				continue
+			}
+			piece, err := ParsePiece(symbol)
+			if err != nil {
+				panic("board: " + err.Error())
+			}
+			board.Squares[row][col] = piece
+		}
+	}
+	return board
+}
+
+// newBoardWithBackRank creates a board with pawns on the second and seventh
+// ranks and the given pieces on the first and eighth
+func newBoardWithBackRank(backRank [8]PieceType) *Board {
 	board := &Board{}
 
 	// Set up pawns
@@ -109,7 +242,6 @@ func NewBoard() *Board {
 	}
 
 	// Set up other pieces
-	backRank := []PieceType{Rook, Knight, Bishop, Queen, King, Bishop, Knight, Rook}
 	for col := 0; col < 8; col++ {
 		board.Squares[0][col] = Piece{Type: backRank[col], Color: Black}
 		board.Squares[7][col] = Piece{Type: backRank[col], Color: White}
@@ -140,38 +272,148 @@ func (b *Board) IsEmpty(pos Position) bool {
 	return b.Squares[pos.Row][pos.Col].Type == Empty
 }
 
-// Print prints the current state of the board
-func (b *Board) Print() {
-	fmt.Println("  a b c d e f g h")
-	fmt.Println(" +-----------------+")
+// View returns a copy of the board with only the pieces of the given
+// color, as a Kriegspiel player sees it
+func (b *Board) View(color Color) *Board {
+	view := &Board{}
 	for row := 0; row < 8; row++ {
-		// Print the row number
-		fmt.Printf("%d|", 8-row)
-
-		// Print each piece in the row
 		for col := 0; col < 8; col++ {
-			fmt.Printf(" %s", b.Squares[row][col])
+			if piece := b.Squares[row][col]; piece.Color == color {
+				view.Squares[row][col] = piece
+			}
 		}
+	}
+	return view
+}
+
+// Pocket counts the pieces of each type a player holds in hand in
+// Crazyhouse, ready to be dropped on the board. It is indexed by piece type.
+type Pocket [7]int
+
+// pocketOrder lists the piece types of a pocket, strongest first
+var pocketOrder = []PieceType{Queen, Rook, Bishop, Knight, Pawn}
 
-		// Print the right border and row number
-		fmt.Printf(" |%d\n", 8-row)
+// Pieces returns the pieces in the pocket, strongest first, as pieces of
+// the given color
+func (p Pocket) Pieces(color Color) []Piece {
+	var pieces []Piece
+	for _, pieceType := range pocketOrder {
+		for i := 0; i < p[pieceType]; i++ {
+			pieces = append(pieces, Piece{Type: pieceType, Color: color})
+		}
 	}
-	fmt.Println(" +-----------------+")
-	fmt.Println("  a b c d e f g h")
+	return pieces
+}
+
+// IsEmpty reports whether the pocket holds no pieces
+func (p Pocket) IsEmpty() bool {
+	return p == Pocket{}
+}
+
+// PrintOptions controls how a board is drawn
+type PrintOptions struct {
+	ASCII   bool       // Use ASCII letters instead of Unicode symbols
+	Flipped bool       // Draw the board from Black's side, with Black at the bottom
+	Pockets *[2]Pocket // The white and black pockets, drawn on the side of each color
+	Hidden  [8][8]bool // Squares drawn shaded instead of their piece, as in Fog of War
+}
+
+// Print prints the current state of the board
+func (b *Board) Print() {
+	b.PrintWith(PrintOptions{})
 }
 
 // PrintASCII prints the board using ASCII characters for better console compatibility
 func (b *Board) PrintASCII() {
-	fmt.Println("  a b c d e f g h")
-	fmt.Println(" +---------------+")
-	for row := 0; row < 8; row++ {
-		fmt.Printf("%d|", 8-row)
-		for col := 0; col < 8; col++ {
+	b.PrintWith(PrintOptions{ASCII: true})
+}
+
+// PrintWithPockets prints the board with the pieces each player holds in
+// hand, black's above the board and white's below
+func (b *Board) PrintWithPockets(white, black Pocket) {
+	b.PrintWith(PrintOptions{Pockets: &[2]Pocket{white, black}})
+}
+
+// PrintASCIIWithPockets prints the board and pockets using ASCII characters
+func (b *Board) PrintASCIIWithPockets(white, black Pocket) {
+	b.PrintWith(PrintOptions{ASCII: true, Pockets: &[2]Pocket{white, black}})
+}
+
+// PrintWith prints the board as drawn by Lines
+func (b *Board) PrintWith(opts PrintOptions) {
+	for _, line := range b.Lines(opts) {
+		fmt.Println(line)
+	}
+}
+
+// Lines returns the lines of the board drawn with the given options, for
+// callers that place it next to other text
+func (b *Board) Lines(opts PrintOptions) []string {
+	border, fog := " +-----------------+", "░"
+	if opts.ASCII {
+		border, fog = " +---------------+", "?"
+	}
+	files := "  a b c d e f g h"
+	top, bottom := Black, White
+	if opts.Flipped {
+		files = "  h g f e d c b a"
+		top, bottom = White, Black
+	}
+
+	var lines []string
+	if opts.Pockets != nil {
+		lines = append(lines, pocketLine(top, opts.Pockets, opts.ASCII))
+	}
+	lines = append(lines, files, border)
+	for r := 0; r < 8; r++ {
+		row := r
+		if opts.Flipped {
+			row = 7 - r
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d|", 8-row)
+		for c := 0; c < 8; c++ {
+			col := c
+			if opts.Flipped {
+				col = 7 - c
+			}
 			piece := b.Squares[row][col]
-			fmt.Printf(" %s", piece.ASCIIString())
+			switch {
+			case opts.Hidden[row][col]:
+				fmt.Fprintf(&sb, " %s", fog)
+			case opts.ASCII:
+				fmt.Fprintf(&sb, " %s", piece.ASCIIString())
+			default:
+				fmt.Fprintf(&sb, " %s", piece)
+			}
+		}
+		fmt.Fprintf(&sb, " |%d", 8-row)
+		lines = append(lines, sb.String())
+	}
+	lines = append(lines, border, files)
+	if opts.Pockets != nil {
+		lines = append(lines, pocketLine(bottom, opts.Pockets, opts.ASCII))
+	}
+	return lines
+}
+
+// pocketLine returns the pieces the player of the given color holds in
+// hand as one line, from the white and black pockets
+func pocketLine(color Color, pockets *[2]Pocket, ascii bool) string {
+	name, pocket := "White", pockets[0]
+	if color == Black {
+		name, pocket = "Black", pockets[1]
+	}
+	symbols := []string{"-"}
+	if !pocket.IsEmpty() {
+		symbols = symbols[:0]
+		for _, piece := range pocket.Pieces(color) {
+			if ascii {
+				symbols = append(symbols, piece.ASCIIString())
+			} else {
+				symbols = append(symbols, piece.String())
+			}
 		}
-		fmt.Printf(" |%d\n", 8-row)
 	}
-	fmt.Println(" +---------------+")
-	fmt.Println("  a b c d e f g h")
+	return fmt.Sprintf("%s in hand: %s", name, strings.Join(symbols, " "))
 }
@@ -85,3 +85,177 @@ func TestMovePiece(t *testing.T) {
 		t.Errorf("Original position not empty after move")
 	}
 }
+
+func TestParsePiece(t *testing.T) {
+	for _, pieceType := range []PieceType{Pawn, Knight, Bishop, Rook, Queen, King} {
+		for _, color := range []Color{White, Black} {
+			want := Piece{Type: pieceType, Color: color}
+			got, err := ParsePiece(rune(want.ASCIIString()[0]))
+			if err != nil {
+				t.Errorf("ParsePiece(%q) error = %v", want.ASCIIString(), err)
+				continue
+			}
+			if got != want {
+				t.Errorf("ParsePiece(%q) = %v, want %v", want.ASCIIString(), got, want)
+			}
+		}
+	}
+
+	if _, err := ParsePiece('x'); err == nil {
+		t.Error("ParsePiece('x') expected an error")
+	}
+}
+
+func TestNewChess960Board(t *testing.T) {
+	tests := []struct {
+		n        int
+		backRank string
+	}{
+		{0, "BBQNNRKR"},
+		{1, "BQNBNRKR"},
+		{518, "RNBQKBNR"},
+		{959, "RKRNNQBB"},
+	}
+
+	for _, tt := range tests {
+		board := NewChess960Board(tt.n)
+		got := ""
+		for col := 0; col < 8; col++ {
+			got += board.Squares[7][col].ASCIIString()
+			if board.Squares[0][col].Type != board.Squares[7][col].Type || board.Squares[0][col].Color != Black {
+				t.Errorf("position %d: black piece on column %d does not mirror white", tt.n, col)
+			}
+		}
+		if got != tt.backRank {
+			t.Errorf("NewChess960Board(%d) back rank = %s, want %s", tt.n, got, tt.backRank)
+		}
+	}
+}
+
+func TestChess960PositionsAreDistinct(t *testing.T) {
+	seen := make(map[[8][8]Piece]int)
+	for n := 0; n < Chess960Positions; n++ {
+		squares := NewChess960Board(n).Squares
+		if other, ok := seen[squares]; ok {
+			t.Fatalf("positions %d and %d are the same", other, n)
+		}
+		seen[squares] = n
+	}
+}
+
+func TestVariantBoards(t *testing.T) {
+	count := func(b *Board) map[Piece]int {
+		pieces := make(map[Piece]int)
+		for row := 0; row < 8; row++ {
+			for col := 0; col < 8; col++ {
+				if p := b.Squares[row][col]; p.Type != Empty {
+					pieces[p]++
+				}
+			}
+		}
+		return pieces
+	}
+
+	horde := NewHordeBoard()
+	if n := count(horde)[Piece{Type: Pawn, Color: White}]; n != 36 {
+		t.Errorf("Horde has %d white pawns, want 36", n)
+	}
+	if p := horde.GetPiece(Position{Row: 3, Col: 1}); p != (Piece{Type: Pawn, Color: White}) {
+		t.Errorf("Horde b5 = %v, want a white pawn", p)
+	}
+	if p := horde.GetPiece(Position{Row: 0, Col: 4}); p != (Piece{Type: King, Color: Black}) {
+		t.Errorf("Horde e8 = %v, want the black king", p)
+	}
+
+	racing := NewRacingKingsBoard()
+	pieces := count(racing)
+	for _, color := range []Color{White, Black} {
+		if pieces[Piece{Type: King, Color: color}] != 1 || pieces[Piece{Type: Pawn, Color: color}] != 0 {
+			t.Errorf("Racing Kings %v pieces = %v", color, pieces)
+		}
+	}
+	if p := racing.GetPiece(Position{Row: 6, Col: 7}); p != (Piece{Type: King, Color: White}) {
+		t.Errorf("Racing Kings h2 = %v, want the white king", p)
+	}
+	if p := racing.GetPiece(Position{Row: 7, Col: 0}); p != (Piece{Type: Queen, Color: Black}) {
+		t.Errorf("Racing Kings a1 = %v, want the black queen", p)
+	}
+}
+
+func TestBoardView(t *testing.T) {
+	b := NewBoard()
+	for _, color := range []Color{White, Black} {
+		view := b.View(color)
+		for row := 0; row < 8; row++ {
+			for col := 0; col < 8; col++ {
+				want := b.Squares[row][col]
+				if want.Color != color {
+					want = Piece{}
+				}
+				if got := view.Squares[row][col]; got != want {
+					t.Errorf("View(%d) square %s = %v, want %v", color, Position{Row: row, Col: col}, got, want)
+				}
+			}
+		}
+	}
+	if b.Squares[0][0].Type != Rook {
+		t.Error("View changed the board")
+	}
+}
+
+func TestPocketPieces(t *testing.T) {
+	var pocket Pocket
+	if !pocket.IsEmpty() || len(pocket.Pieces(White)) != 0 {
+		t.Errorf("empty pocket has pieces")
+	}
+
+	pocket[Pawn] = 2
+	pocket[Knight] = 1
+	pocket[Queen] = 1
+	var got string
+	for _, piece := range pocket.Pieces(Black) {
+		got += piece.ASCIIString()
+	}
+	if want := "qnpp"; got != want {
+		t.Errorf("Pieces() = %q, want %q", got, want)
+	}
+}
+
+func TestBoardLines(t *testing.T) {
+	b := NewBoard()
+	var hidden [8][8]bool
+	hidden[0][4] = true // e8
+	lines := b.Lines(PrintOptions{ASCII: true, Hidden: hidden, Pockets: &[2]Pocket{{Pawn: 1}, {}}})
+	want := []string{
+		"Black in hand: -",
+		"  a b c d e f g h",
+		" +---------------+",
+		"8| r n b q ? b n r |8",
+		"7| p p p p p p p p |7",
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("line %d = %q, want %q", i, lines[i], line)
+		}
+	}
+	if last := lines[len(lines)-1]; last != "White in hand: P" {
+		t.Errorf("last line = %q, want %q", last, "White in hand: P")
+	}
+
+	// A flipped board has White's pocket and first rank on top
+	lines = b.Lines(PrintOptions{ASCII: true, Flipped: true, Pockets: &[2]Pocket{{Pawn: 1}, {}}})
+	want = []string{
+		"White in hand: P",
+		"  h g f e d c b a",
+		" +---------------+",
+		"1| R N B K Q B N R |1",
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("flipped line %d = %q, want %q", i, lines[i], line)
+		}
+	}
+	if last := lines[len(lines)-1]; last != "Black in hand: -" {
+		t.Errorf("flipped last line = %q, want %q", last, "Black in hand: -")
+	}
+}
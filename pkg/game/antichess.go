@@ -0,0 +1,92 @@
+package game
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// antichess is won by losing every piece or by being stalemated. Captures
+// are compulsory, the king is an ordinary piece, and there is no check and
+// no castling.
+type antichess struct{ standard }
+
+func (antichess) Name() string {
+	return "Antichess"
+}
+
+func (antichess) Setup(n int) bitboard.Position {
+	p := bitboard.StartPosition()
+	p.SetRules(p.Rules() | bitboard.Antichess)
+	p.SetCastling(bitboard.NoCastling)
+	return p
+}
+
+// LegalMoves leaves only the captures when there are any
+func (antichess) LegalMoves(p *bitboard.Position) []bitboard.Move {
+	moves := p.LegalMoves()
+	captures := moves[:0]
+	for _, m := range moves {
+		if p.IsCapture(m) {
+			captures = append(captures, m)
+		}
+	}
+	if len(captures) == 0 {
+		// Nothing was overwritten, as no move was kept
+		return moves
+	}
+	return captures
+}
+
+// ParseFEN ignores castling rights, as there is no castling
+func (antichess) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	p, err := parse(fields)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	p.SetRules(p.Rules() | bitboard.Antichess)
+	p.SetCastling(bitboard.NoCastling)
+	return p, nil
+}
+
+func (antichess) Result(g *Game, result Result) Result {
+	// The player without a move wins, whether or not pieces are left
+	if result.Reason == ReasonStalemate {
+		if g.pos.Color(g.CurrentPlayer) == 0 {
+			return win(g.CurrentPlayer, ReasonNoPieces)
+		}
+		return win(g.CurrentPlayer, ReasonStalemate)
+	}
+
+	// Kings can be captured, so a lone piece can still be lost
+	result = ignoreMaterialDraws(g, result)
+
+	if !result.IsOver() && bishopsCannotMeet(&g.pos) {
+		return draw(ReasonInsufficientMaterial)
+	}
+	return result
+}
+
+// bishopsCannotMeet reports whether only bishops are left, with all of
+// one side's bishops on light squares and all of the other's on dark
+// squares, so that no piece can ever be captured
+func bishopsCannotMeet(p *bitboard.Position) bool {
+	bishops := p.Pieces(board.White, board.Bishop) | p.Pieces(board.Black, board.Bishop)
+	if bishops != p.Occupied() {
+		return false
+	}
+	white, black := p.Pieces(board.White, board.Bishop), p.Pieces(board.Black, board.Bishop)
+	return white != 0 && black != 0 &&
+		((white&lightSquares == white && black&lightSquares == 0) ||
+			(white&lightSquares == 0 && black&lightSquares == black))
+}
+
+// lightSquares holds the light squares of the board, a8 being one
+var lightSquares = func() bitboard.Bitboard {
+	var light bitboard.Bitboard
+	for s := bitboard.Square(0); s < 64; s++ {
+		if (s.Row()+s.Col())%2 == 0 {
+			light |= s.Bitboard()
+		}
+	}
+	return light
+}()
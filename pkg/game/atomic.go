@@ -0,0 +1,64 @@
+package game
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// atomic makes every capture an explosion that blows up the capturing
+// piece and every piece but a pawn next to the capture square. Blowing up
+// the enemy king wins, and kings cannot capture.
+type atomic struct{ standard }
+
+func (atomic) Name() string {
+	return "Atomic"
+}
+
+func (atomic) Setup(n int) bitboard.Position {
+	p := bitboard.StartPosition()
+	p.SetRules(p.Rules() | bitboard.Atomic)
+	return p
+}
+
+func (atomic) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	p, err := parse(fields)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	p.SetRules(p.Rules() | bitboard.Atomic)
+	return p, nil
+}
+
+func (atomic) Result(g *Game, result Result) Result {
+	for _, color := range []board.Color{board.White, board.Black} {
+		if g.pos.King(color) == bitboard.NoSquare {
+			return win(opponentOf(color), ReasonExplosion)
+		}
+	}
+
+	// Two bishops or knights can still blow each other up next to a king,
+	// and time only runs out in a draw against a side that cannot win
+	switch result.Reason {
+	case ReasonInsufficientMaterial:
+		if canExplode(&g.pos, board.White) || canExplode(&g.pos, board.Black) {
+			return Result{}
+		}
+	case ReasonTimeout, ReasonTimeoutVsInsufficientMaterial:
+		if canExplode(&g.pos, g.opponent()) {
+			return win(g.opponent(), ReasonTimeout)
+		}
+		return draw(ReasonTimeoutVsInsufficientMaterial)
+	}
+	return result
+}
+
+// canExplode reports whether the given color has the material to win an
+// Atomic game. A lone king can neither capture nor give check, and a
+// single knight or bishop finds nothing to blow up next to a bare king.
+func canExplode(p *bitboard.Position, color board.Color) bool {
+	them := opponentOf(color)
+	pieces := p.Color(color) &^ p.Pieces(color, board.King)
+	minors := p.Pieces(color, board.Knight) | p.Pieces(color, board.Bishop)
+	bareKing := p.Color(them) == p.Pieces(them, board.King)
+	return pieces != 0 && !(bareKing && pieces.Count() == 1 && pieces == minors)
+}
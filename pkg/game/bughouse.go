@@ -0,0 +1,137 @@
+package game
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// bughouse is Crazyhouse for two teams of two, played on two boards at
+// once. A captured piece goes to the pocket of the capturer's partner, who
+// plays the other color on the other board. A Match links the two games;
+// a Bughouse game on its own throws captured pieces away.
+type bughouse struct{ crazyhouse }
+
+func (bughouse) Name() string {
+	return "Bughouse"
+}
+
+func (b bughouse) Setup(n int) bitboard.Position {
+	p := b.crazyhouse.Setup(n)
+	p.SetRules(p.Rules() | bitboard.Bughouse)
+	return p
+}
+
+func (bughouse) Result(g *Game, result Result) Result {
+	// The partner can send pieces at any time, so material never runs out
+	return ignoreMaterialDraws(g, result)
+}
+
+func (b bughouse) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	p, err := b.crazyhouse.ParseFEN(fields, parse)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	p.SetRules(p.Rules() | bitboard.Bughouse)
+	return p, nil
+}
+
+// Match is a Bughouse match: two games played at the same time by two
+// teams. Team 0 plays White on board 0 and Black on board 1, team 1 the
+// other colors, so that partners play opposite colors. Each board has its
+// own clocks, and the match ends as soon as either game ends.
+type Match struct {
+	boards [2]*Game
+}
+
+// NewMatch creates a Bughouse match with both games at the starting
+// position. Each game starts with the usual time control, which can be
+// replaced through Board.
+func NewMatch() *Match {
+	m := &Match{}
+	for i := range m.boards {
+		m.boards[i] = NewGame(WithVariant(Bughouse))
+	}
+	m.boards[0].partner = m.boards[1]
+	m.boards[1].partner = m.boards[0]
+	return m
+}
+
+// Board returns the game played on board 0 or 1. Moves should be played
+// through the match, which refuses them once the other board has ended.
+func (m *Match) Board(i int) *Game {
+	return m.boards[i]
+}
+
+// Team returns the team, 0 or 1, of the player of the given color on a
+// board
+func (m *Match) Team(i int, color board.Color) int {
+	if color == board.Black {
+		return 1 - i
+	}
+	return i
+}
+
+// PlayMove plays a move on a board. A piece it captures goes to the pocket
+// of the same color on the other board.
+func (m *Match) PlayMove(i int, move Move) error {
+	if m.IsOver() {
+		return errGameOver
+	}
+	return m.boards[i].PlayMove(move)
+}
+
+// Result returns the board the match was decided on and the result of the
+// game there, or -1 and an Ongoing result while both games go on. The
+// running clocks of both boards are checked, so a player who runs out of
+// time loses even while waiting for pieces from their partner.
+func (m *Match) Result() (int, Result) {
+	for i, g := range m.boards {
+		if !g.IsOver() && g.flagFallen() {
+			g.timeOut()
+		}
+		if g.IsOver() {
+			return i, g.Result()
+		}
+	}
+	return -1, Result{}
+}
+
+// IsOver reports whether either game has ended
+func (m *Match) IsOver() bool {
+	_, result := m.Result()
+	return result.IsOver()
+}
+
+// flagFallen reports whether the player to move has used up their time,
+// counting the time spent on the current move
+func (g *Game) flagFallen() bool {
+	tc := g.TimeControl
+	if tc == nil {
+		return false
+	}
+	timeLeft := tc.BlackTimeLeft
+	if g.CurrentPlayer == board.White {
+		timeLeft = tc.WhiteTimeLeft
+	}
+	return timeLeft-tc.Elapsed() <= 0
+}
+
+// passCaptured gives the piece a move is about to capture to the partner's
+// board, in the pocket of the captured piece's color. Promoted pieces go
+// back to pawns.
+func (g *Game) passCaptured(m Move) {
+	captured, square := g.capturedBy(m)
+	if g.partner == nil || captured.Type == board.Empty {
+		return
+	}
+	pieceType := captured.Type
+	if g.pos.Promoted().Has(bitboard.SquareOf(square)) {
+		pieceType = board.Pawn
+	}
+
+	partner := g.partner
+	pocket := partner.pos.Pocket(captured.Color)
+	pocket[pieceType]++
+	partner.pos.SetPocket(captured.Color, pocket)
+	partner.positionHashes[len(partner.positionHashes)-1] = partner.pos.Hash()
+}
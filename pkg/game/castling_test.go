@@ -0,0 +1,192 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// castlingMoves returns which castling moves are legal for the side to move
+func castlingMoves(g *Game) (kingSide, queenSide bool) {
+	for _, move := range g.LegalMoves() {
+		switch g.castlingRight(move) {
+		case bitboard.KingSide(g.CurrentPlayer):
+			kingSide = true
+		case bitboard.QueenSide(g.CurrentPlayer):
+			queenSide = true
+		}
+	}
+	return kingSide, queenSide
+}
+
+func TestCastlingLegality(t *testing.T) {
+	tests := []struct {
+		name          string
+		fen           string
+		wantKingSide  bool
+		wantQueenSide bool
+	}{
+		{"both sides free", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", true, true},
+		{"black both sides free", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", true, true},
+		{"no rights", "r3k2r/8/8/8/8/8/8/R3K2R w kq - 0 1", false, false},
+		{"king in check", "4rk2/8/8/8/8/8/8/R3K2R w KQ - 0 1", false, false},
+		{"transit square attacked", "4kr2/8/8/8/8/8/8/R3K2R w KQ - 0 1", false, true},
+		{"landing square attacked", "4k1r1/8/8/8/8/8/8/R3K2R w KQ - 0 1", false, true},
+		{"queenside transit attacked", "3rk3/8/8/8/8/8/8/R3K2R w KQ - 0 1", true, false},
+		{"queenside landing attacked", "2r1k3/8/8/8/8/8/8/R3K2R w KQ - 0 1", true, false},
+		{"b1 attacked does not matter", "1r2k3/8/8/8/8/8/8/R3K2R w KQ - 0 1", true, true},
+		{"attacked rook does not matter", "4k2r/8/8/8/8/8/8/R3K2R w KQ - 0 1", true, true},
+		{"pawn attacks both transit squares", "4k3/8/8/8/8/8/4p3/R3K2R w KQ - 0 1", false, false},
+		{"knight attacks transit squares", "4k3/8/8/8/8/4n3/8/R3K2R w KQ - 0 1", false, false},
+		{"bishop attacks landing square", "4k3/8/8/8/8/8/7b/R3K2R w KQ - 0 1", false, true},
+		{"bishop attacks transit square", "4k3/8/8/1b6/8/8/8/R3K2R w KQ - 0 1", false, true},
+		{"piece on b1 blocks queenside", "r3k2r/8/8/8/8/8/8/RN2K2R w KQkq - 0 1", true, false},
+		{"own piece on g1 blocks kingside", "r3k2r/8/8/8/8/8/8/R3K1NR w KQkq - 0 1", false, true},
+		{"enemy piece blocks kingside", "r3k2r/8/8/8/8/8/8/R3Kn1R w KQkq - 0 1", false, true},
+		{"black transit attacked", "r3k2r/8/8/8/8/8/8/5RK1 b kq - 0 1", false, true},
+		{"black queenside landing attacked", "r3k2r/8/8/8/8/8/8/2R2K2 b kq - 0 1", true, false},
+		{"rook missing despite rights", "4k3/8/8/8/8/8/8/4K2R w KQ - 0 1", true, false},
+		{"king off its square", "4k3/8/8/8/8/8/8/R4K1R w KQ - 0 1", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kingSide, queenSide := castlingMoves(g)
+			if kingSide != tt.wantKingSide || queenSide != tt.wantQueenSide {
+				t.Errorf("castling = (%v, %v), want (%v, %v)", kingSide, queenSide, tt.wantKingSide, tt.wantQueenSide)
+			}
+		})
+	}
+}
+
+func TestCastlingWithoutRook(t *testing.T) {
+	// Rights that are not backed by a rook must not allow castling, even
+	// when they were set without going through FEN
+	g := emptyGame()
+	place(t, g, "e1", board.King, board.White)
+	place(t, g, "h1", board.Bishop, board.White)
+	place(t, g, "a1", board.Knight, board.Black)
+	place(t, g, "e8", board.King, board.Black)
+	g.pos.SetCastling(bitboard.WhiteKingSide | bitboard.WhiteQueenSide)
+
+	kingSide, queenSide := castlingMoves(g)
+	if kingSide || queenSide {
+		t.Errorf("castling = (%v, %v) without own rooks", kingSide, queenSide)
+	}
+}
+
+func TestCastlingRightsTracking(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		moves    []string
+		castling string
+	}{
+		{
+			name:     "rook captured on its square",
+			fen:      "r3k2r/8/8/8/8/8/6b1/R3K2R b KQkq - 0 1",
+			moves:    []string{"g2 h1"},
+			castling: "Qkq",
+		},
+		{
+			name:     "capturing rook loses both rights",
+			fen:      "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			moves:    []string{"a1 a8"},
+			castling: "Kk",
+		},
+		{
+			name:     "rook leaves and returns",
+			fen:      "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			moves:    []string{"h1 h2", "a8 b8", "h2 h1"},
+			castling: "Qk",
+		},
+		{
+			name:     "king move loses both rights",
+			fen:      "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+			moves:    []string{"e8 e7"},
+			castling: "KQ",
+		},
+		{
+			name:     "castling loses both rights",
+			fen:      "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			moves:    []string{"e1 c1"},
+			castling: "kq",
+		},
+		{
+			name:     "other rook on the file keeps rights",
+			fen:      "r3k2r/8/8/8/8/8/R7/R3K2R w KQkq - 0 1",
+			moves:    []string{"a2 a3"},
+			castling: "KQkq",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			playMoves(t, g, tt.moves...)
+
+			if got := strings.Fields(g.FEN())[2]; got != tt.castling {
+				t.Errorf("castling rights = %q, want %q", got, tt.castling)
+			}
+		})
+	}
+
+	// A rook that reaches the corner after the original was captured does
+	// not restore the right
+	g, err := NewGameFromFEN("r3k2r/8/8/8/8/8/6b1/R3K2R b KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "g2 h1", "a1 a2", "h1 d5", "a2 h2", "d5 e6", "h2 h1", "e6 d5")
+	if kingSide, _ := castlingMoves(g); kingSide {
+		t.Error("castling with a rook that was not the original one")
+	}
+}
+
+func TestCastlingMovesRook(t *testing.T) {
+	g, err := NewGameFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "e1 g1", "e8 c8")
+
+	if want := "2kr3r/8/8/8/8/8/8/R4RK1 w - - 2 2"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+}
+
+func TestIsValidMoveCastling(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		variant  Variant
+		from, to string
+	}{
+		{"standard", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", Standard, "e1", "g1"},
+		{"chess960 king onto its rook", "r3k2r/8/8/8/8/8/8/1R2K1R1 w Gq - 0 1", Chess960, "e1", "g1"},
+		{"chess960 king to its target", "r3k2r/8/8/8/8/8/8/R3K1R1 w GA - 0 1", Chess960, "e1", "c1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(tt.variant))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !g.IsValidMove(mustPos(t, tt.from), mustPos(t, tt.to)) {
+				t.Errorf("IsValidMove(%s, %s) = false for a legal castling move", tt.from, tt.to)
+			}
+		})
+	}
+}
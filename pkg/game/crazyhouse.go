@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// crazyhouse puts captured pieces in the capturer's pocket, from where
+// they can be dropped back on the board as a move of their own
+type crazyhouse struct{ standard }
+
+func (crazyhouse) Name() string {
+	return "Crazyhouse"
+}
+
+func (crazyhouse) Setup(n int) bitboard.Position {
+	p := bitboard.StartPosition()
+	p.SetRules(p.Rules() | bitboard.Crazyhouse)
+	return p
+}
+
+func (crazyhouse) Result(g *Game, result Result) Result {
+	// Captured pieces come back, so material never runs out while a
+	// player has something to drop
+	switch result.Reason {
+	case ReasonInsufficientMaterial, ReasonDeadPosition:
+		if !g.pos.Pocket(board.White).IsEmpty() || !g.pos.Pocket(board.Black).IsEmpty() {
+			return Result{}
+		}
+	case ReasonTimeoutVsInsufficientMaterial:
+		if !g.pos.Pocket(g.opponent()).IsEmpty() {
+			return win(g.opponent(), ReasonTimeout)
+		}
+	}
+	return result
+}
+
+// FormatFEN marks promoted pieces with a tilde and adds the pockets to the
+// piece placement in brackets, as in "Q~" and "[Nnp]"
+func (crazyhouse) FormatFEN(p *bitboard.Position, fields []string) []string {
+	var sb strings.Builder
+	s := bitboard.Square(0)
+	for _, symbol := range fields[0] {
+		sb.WriteRune(symbol)
+		switch {
+		case symbol >= '1' && symbol <= '8':
+			s += bitboard.Square(symbol - '0')
+		case symbol != '/':
+			if p.Promoted().Has(s) {
+				sb.WriteByte('~')
+			}
+			s++
+		}
+	}
+
+	sb.WriteByte('[')
+	for _, color := range []board.Color{board.White, board.Black} {
+		for _, piece := range p.Pocket(color).Pieces(color) {
+			sb.WriteString(piece.ASCIIString())
+		}
+	}
+	sb.WriteByte(']')
+
+	fields = append([]string(nil), fields...)
+	fields[0] = sb.String()
+	return fields
+}
+
+// ParseFEN reads the pockets written in brackets after the piece
+// placement, or as a ninth rank, and the tildes after promoted pieces. A
+// FEN without pockets starts with both pockets empty.
+func (crazyhouse) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	if len(fields) == 0 {
+		return parse(fields)
+	}
+
+	placement, pockets := fields[0], ""
+	if i := strings.IndexByte(placement, '['); i >= 0 {
+		if !strings.HasSuffix(placement, "]") {
+			return bitboard.Position{}, fmt.Errorf("invalid pockets %q", placement[i:])
+		}
+		placement, pockets = placement[:i], placement[i+1:len(placement)-1]
+	} else if ranks := strings.Split(placement, "/"); len(ranks) == 9 {
+		placement, pockets = strings.Join(ranks[:8], "/"), ranks[8]
+	}
+
+	// Take out the tildes, noting the squares of the promoted pieces
+	var promoted bitboard.Bitboard
+	var sb strings.Builder
+	s := bitboard.Square(0)
+	for _, symbol := range placement {
+		switch {
+		case symbol == '~':
+			promoted |= (s - 1).Bitboard()
+			continue
+		case symbol >= '1' && symbol <= '8':
+			s += bitboard.Square(symbol - '0')
+		case symbol != '/':
+			s++
+		}
+		sb.WriteRune(symbol)
+	}
+
+	var pocket [3]board.Pocket
+	for _, symbol := range pockets {
+		piece, err := board.ParsePiece(symbol)
+		if err != nil || piece.Type == board.King {
+			return bitboard.Position{}, fmt.Errorf("invalid pockets %q", pockets)
+		}
+		pocket[piece.Color][piece.Type]++
+		if pocket[piece.Color][piece.Type] > 16 {
+			return bitboard.Position{}, fmt.Errorf("too many pieces in pockets %q", pockets)
+		}
+	}
+
+	fields = append([]string{sb.String()}, fields[1:]...)
+	p, err := parse(fields)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	p.SetRules(p.Rules() | bitboard.Crazyhouse)
+	p.SetPocket(board.White, pocket[board.White])
+	p.SetPocket(board.Black, pocket[board.Black])
+	p.SetPromoted(promoted & p.Occupied())
+	return p, nil
+}
+
+// Pocket returns the pieces the player of the given color holds in hand.
+// Only Crazyhouse has pockets; in other variants they are empty.
+func (g *Game) Pocket(color board.Color) board.Pocket {
+	return g.pos.Pocket(color)
+}
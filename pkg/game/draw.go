@@ -0,0 +1,141 @@
+package game
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/piece"
+)
+
+// hasInsufficientMaterial reports whether neither side can possibly mate:
+// king against king, king and one minor piece against king, or kings and
+// any number of bishops that all stand on squares of the same color
+func (g *Game) hasInsufficientMaterial() bool {
+	return g.cannotMate(board.White, board.Black)
+}
+
+// cannotMate reports whether the pieces of the given colors together could
+// never give mate: kings with at most one knight or bishop between them,
+// or with bishops that all stand on squares of the same color
+func (g *Game) cannotMate(colors ...board.Color) bool {
+	var knights, bishops bitboard.Bitboard
+	for _, color := range colors {
+		for _, pieceType := range []board.PieceType{board.Pawn, board.Rook, board.Queen} {
+			if g.pos.Pieces(color, pieceType) != 0 {
+				return false
+			}
+		}
+		knights |= g.pos.Pieces(color, board.Knight)
+		bishops |= g.pos.Pieces(color, board.Bishop)
+	}
+	oneColor := bishops&lightSquares == 0 || bishops&^lightSquares == 0
+	return (knights|bishops).Count() <= 1 || (knights == 0 && oneColor)
+}
+
+// isDeadPosition reports whether the position is a fully locked pawn
+// structure that neither king can break into. Only kings and pawns may be
+// left, every pawn must be blocked by an enemy pawn with nothing to
+// capture, and neither king may reach an enemy pawn it could take.
+func (g *Game) isDeadPosition() bool {
+	pawns := g.piecesOfType(board.Pawn)
+	if len(pawns) == 0 {
+		return false
+	}
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			switch g.Board.GetPiece(board.Position{Row: row, Col: col}).Type {
+			case board.Empty, board.King, board.Pawn:
+			default:
+				return false
+			}
+		}
+	}
+
+	// No pawn can ever move again if each one is blocked head-on and none
+	// can capture, since the other pawns cannot move either
+	for _, pos := range pawns {
+		p := g.Board.GetPiece(pos)
+		enemyPawn := board.Piece{Type: board.Pawn, Color: opponentOf(p.Color)}
+		ahead := board.Position{Row: pos.Row + 1, Col: pos.Col}
+		if p.Color == board.White {
+			ahead.Row = pos.Row - 1
+		}
+		if g.Board.GetPiece(ahead) != enemyPawn {
+			return false
+		}
+		for _, target := range (piece.PawnValidator{}).Attacks(pos, g.Board) {
+			if g.Board.GetPiece(target) == enemyPawn {
+				return false
+			}
+		}
+	}
+
+	for _, color := range []board.Color{board.White, board.Black} {
+		if g.kingCanReachPawn(color) {
+			return false
+		}
+	}
+	return true
+}
+
+// kingCanReachPawn reports whether the king of the given color can walk
+// to an enemy pawn that is not defended by another pawn, avoiding squares
+// attacked by enemy pawns. The pawn structure is assumed to be locked.
+func (g *Game) kingCanReachPawn(color board.Color) bool {
+	kings := g.piecesOfType(board.King)
+	var start board.Position
+	for _, pos := range kings {
+		if g.Board.GetPiece(pos).Color == color {
+			start = pos
+		}
+	}
+
+	enemy := opponentOf(color)
+	guarded := make(map[board.Position]bool)
+	for _, pos := range g.piecesOfType(board.Pawn) {
+		if p := g.Board.GetPiece(pos); p.Color == enemy {
+			for _, target := range (piece.PawnValidator{}).Attacks(pos, g.Board) {
+				guarded[target] = true
+			}
+		}
+	}
+
+	visited := map[board.Position]bool{start: true}
+	queue := []board.Position{start}
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+		for dRow := -1; dRow <= 1; dRow++ {
+			for dCol := -1; dCol <= 1; dCol++ {
+				next := board.Position{Row: pos.Row + dRow, Col: pos.Col + dCol}
+				if next.Row < 0 || next.Row > 7 || next.Col < 0 || next.Col > 7 || visited[next] || guarded[next] {
+					continue
+				}
+				visited[next] = true
+
+				p := g.Board.GetPiece(next)
+				if p.Type == board.Pawn {
+					if p.Color == enemy {
+						return true
+					}
+					continue
+				}
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// piecesOfType returns the squares holding pieces of the given type
+func (g *Game) piecesOfType(pieceType board.PieceType) []board.Position {
+	var squares []board.Position
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			pos := board.Position{Row: row, Col: col}
+			if g.Board.GetPiece(pos).Type == pieceType {
+				squares = append(squares, pos)
+			}
+		}
+	}
+	return squares
+}
@@ -0,0 +1,56 @@
+package game
+
+import "testing"
+
+func TestAutomaticDraws(t *testing.T) {
+	tests := []struct {
+		name       string
+		fen        string
+		wantStatus string
+	}{
+		{"king against king", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", "Draw by insufficient material"},
+		{"king and knight", "4k3/8/8/8/8/8/8/4KN2 w - - 0 1", "Draw by insufficient material"},
+		{"king and bishop", "4k3/8/8/8/8/8/8/2B1K3 b - - 0 1", "Draw by insufficient material"},
+		{"bishops on the same color", "4kb2/8/8/8/8/8/8/2B1K3 w - - 0 1", "Draw by insufficient material"},
+		{"two bishops on the same color", "4k3/8/8/8/8/8/8/B1B1K3 w - - 0 1", "Draw by insufficient material"},
+		{"bishops on opposite colors", "2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", "White to move"},
+		{"two knights", "4k3/8/8/8/8/8/8/3NKN2 w - - 0 1", "White to move"},
+		{"knight against knight", "4kn2/8/8/8/8/8/8/4KN2 w - - 0 1", "White to move"},
+		{"knight and bishop", "4k3/8/8/8/8/8/8/2B1KN2 w - - 0 1", "White to move"},
+		{"a pawn left", "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", "White to move"},
+		{"locked pawn chain", "4k3/8/8/1p1p1p1p/1P1P1P1P/8/8/4K3 w - - 0 1", "Draw by dead position"},
+		{"locked diagonal chain", "7k/8/8/p1p1p1p1/P1P1P1P1/8/8/K7 b - - 0 1", "Draw by dead position"},
+		{"king can walk around the chain", "4k3/8/8/1p1p1p2/1P1P1P2/8/8/4K3 w - - 0 1", "White to move"},
+		{"pawn can still capture", "4k3/8/8/3pp3/3PP3/8/8/4K3 w - - 0 1", "White to move"},
+		{"pawn can still move", "4k3/8/8/1p1p1p2/1P1P1P1P/8/8/4K3 w - - 0 1", "White to move"},
+		{"a piece is left", "4k3/8/8/1p1p1p1p/1P1P1P1P/8/8/4KN2 w - - 0 1", "White to move"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.GetGameStatus(); got != tt.wantStatus {
+				t.Errorf("GetGameStatus() = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCaptureLeavesInsufficientMaterial(t *testing.T) {
+	g, err := NewGameFromFEN("4k3/8/8/8/8/8/8/3rK3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "e1 d1")
+
+	if g.State != Draw || g.GetGameStatus() != "Draw by insufficient material" {
+		t.Errorf("State = %v, status %q", g.State, g.GetGameStatus())
+	}
+	if err := g.MakeMove(mustPos(t, "e8"), mustPos(t, "e7")); err == nil {
+		t.Error("MakeMove after the game was drawn succeeded")
+	}
+}
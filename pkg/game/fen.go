@@ -0,0 +1,312 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// StartFEN is the FEN of the standard starting position
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// NewGameFromFEN creates a new chess game starting from a FEN position.
+// The half-move clock and full-move number may be omitted, in which case
+// they default to 0 and 1. The castling field may be in X-FEN or
+// Shredder-FEN. The game is played as the variant WithVariant chooses, and
+// a standard game becomes Chess960 if the castling rights can only be read
+// as Chess960 ones.
+func NewGameFromFEN(fen string, opts ...Option) (*Game, error) {
+	variant := newOptions(opts).variant
+	pos, err := variant.ParseFEN(strings.Fields(fen), func(fields []string) (bitboard.Position, error) {
+		return parseFEN(fields, variant == Chess960)
+	})
+	if err == nil {
+		err = validatePosition(&pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %v", fen, err)
+	}
+	if variant == Standard && pos.Rules().Has(bitboard.Chess960) {
+		variant = Chess960
+	}
+
+	g := NewGame(WithVariant(variant), WithStartPosition(standardPosition))
+	start := g.FEN()
+	g.setPosition(pos)
+
+	if position := g.FEN(); position != start || variant == Chess960 {
+		g.startFEN = position
+	}
+
+	g.resetHash()
+	g.updateGameState()
+	return g, nil
+}
+
+// validatePosition checks that each side has one king, that no pawn is on
+// the first or last rank and that the side not to move is not in check. It
+// runs once the variant has set its rules, as in Atomic touching kings are
+// not in check, in Antichess any number of kings is allowed and in Horde
+// White has no king and pawns on the first rank.
+func validatePosition(p *bitboard.Position) error {
+	for _, color := range []board.Color{board.White, board.Black} {
+		kings := p.Pieces(color, board.King).Count()
+		switch {
+		case p.Rules().Has(bitboard.Antichess):
+		case p.Rules().Has(bitboard.Horde) && color == board.White:
+			if kings != 0 {
+				return errors.New("the horde cannot have a king")
+			}
+		case kings != 1:
+			return errors.New("each side must have exactly one king")
+		}
+
+		firstRankPawns := p.Rules().Has(bitboard.Horde) && color == board.White
+		for _, s := range p.Pieces(color, board.Pawn).Squares() {
+			if s.Row() == bitboard.HomeRow(opponentOf(color)) || (s.Row() == bitboard.HomeRow(color) && !firstRankPawns) {
+				return errors.New("pawn on the first or last rank")
+			}
+		}
+	}
+	if p.InCheck(opponentOf(p.SideToMove())) {
+		return errors.New("the side not to move is in check")
+	}
+	return nil
+}
+
+// parseFEN reads a position from the fields of a FEN string. Chess960
+// castling is used if chess960 is set or the castling rights name rooks
+// by file.
+func parseFEN(fields []string, chess960 bool) (bitboard.Position, error) {
+	if len(fields) != 4 && len(fields) != 6 {
+		return bitboard.Position{}, fmt.Errorf("expected 6 fields, got %d", len(fields))
+	}
+
+	b, err := parseFENBoard(fields[0])
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+
+	var side board.Color
+	switch fields[1] {
+	case "w":
+		side = board.White
+	case "b":
+		side = board.Black
+	default:
+		return bitboard.Position{}, fmt.Errorf("invalid side to move %q", fields[1])
+	}
+	pos := bitboard.NewPosition(b, side)
+
+	// Rights whose king or rook has moved are dropped
+	if err := parseFENCastling(fields[2], &pos, chess960); err != nil {
+		return bitboard.Position{}, err
+	}
+
+	if fields[3] != "-" {
+		// The en passant square is behind a pawn of the side not to move
+		wantRow := 2
+		if side == board.Black {
+			wantRow = 5
+		}
+		target, err := board.NewPosition(fields[3])
+		if err != nil || target.Row != wantRow {
+			return bitboard.Position{}, fmt.Errorf("invalid en passant square %q", fields[3])
+		}
+		pos.SetEnPassant(bitboard.SquareOf(target))
+	}
+
+	if len(fields) == 6 {
+		halfMoveClock, err := strconv.Atoi(fields[4])
+		if err != nil || halfMoveClock < 0 {
+			return bitboard.Position{}, fmt.Errorf("invalid half-move clock %q", fields[4])
+		}
+		fullMoveNumber, err := strconv.Atoi(fields[5])
+		if err != nil || fullMoveNumber < 1 {
+			return bitboard.Position{}, fmt.Errorf("invalid full-move number %q", fields[5])
+		}
+		pos.SetClocks(halfMoveClock, fullMoveNumber)
+	}
+	return pos, nil
+}
+
+// parseFENBoard parses the piece placement field of a FEN string
+func parseFENBoard(placement string) (*board.Board, error) {
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("expected 8 ranks, got %d", len(ranks))
+	}
+
+	b := &board.Board{}
+	for row, rank := range ranks {
+		col := 0
+		for _, symbol := range rank {
+			if symbol >= '1' && symbol <= '8' {
+				col += int(symbol - '0')
+				continue
+			}
+
+			piece, err := board.ParsePiece(symbol)
+			if err != nil {
+				return nil, err
+			}
+			if col > 7 {
+				return nil, fmt.Errorf("rank %d has more than 8 squares", 8-row)
+			}
+			b.SetPiece(board.Position{Row: row, Col: col}, piece)
+			col++
+		}
+		if col != 8 {
+			return nil, fmt.Errorf("rank %d does not have 8 squares", 8-row)
+		}
+	}
+
+	return b, nil
+}
+
+// parseFENCastling parses the castling availability field of a FEN string
+// and sets the castling rights of p. K and Q stand for the outermost rook on
+// each side of the king, and a file letter for the rook on that file. It
+// switches p to Chess960 castling when chess960 is set or the field names
+// rooks by file.
+func parseFENCastling(field string, p *bitboard.Position, chess960 bool) error {
+	if field != "-" && strings.ContainsAny(strings.ToUpper(field), "ABCDEFGH") {
+		chess960 = true
+	}
+	if chess960 {
+		p.SetRules(p.Rules() | bitboard.Chess960)
+	}
+	if field == "-" {
+		p.SetCastling(bitboard.NoCastling)
+		return nil
+	}
+
+	rights := bitboard.NoCastling
+	for _, symbol := range field {
+		color := board.White
+		if unicode.IsLower(symbol) {
+			color = board.Black
+		}
+		king := p.King(color)
+
+		var right bitboard.CastlingRights
+		var rook bitboard.Square
+		switch upper := unicode.ToUpper(symbol); {
+		case upper == 'K':
+			right = bitboard.KingSide(color)
+			rook = outermostRook(p, right)
+		case upper == 'Q':
+			right = bitboard.QueenSide(color)
+			rook = outermostRook(p, right)
+		case upper >= 'A' && upper <= 'H':
+			col := int(upper - 'A')
+			right = bitboard.KingSide(color)
+			if col < king.Col() {
+				right = bitboard.QueenSide(color)
+			}
+			rook = bitboard.SquareAt(bitboard.HomeRow(color), col)
+		default:
+			return fmt.Errorf("invalid castling availability %q", field)
+		}
+		rights |= right
+		// Outside Chess960 castling is always with the rooks in the corners
+		if chess960 && rook != bitboard.NoSquare {
+			p.SetCastlingRook(right, rook)
+		}
+	}
+
+	p.SetCastling(rights)
+	return nil
+}
+
+// FEN returns the current position in Forsyth-Edwards Notation. Chess960
+// castling rights are written in X-FEN, which names the rook's file when
+// another rook stands further out on the same side.
+func (g *Game) FEN() string {
+	return g.fen(false)
+}
+
+// ShredderFEN returns the current position in FEN with the castling rights
+// written as the files of the rooks, as in Shredder-FEN
+func (g *Game) ShredderFEN() string {
+	return g.fen(true)
+}
+
+// fen writes the current position in FEN, with the castling rights written
+// as files if shredder is set
+func (g *Game) fen(shredder bool) string {
+	var sb strings.Builder
+	for row := 0; row < 8; row++ {
+		empty := 0
+		for col := 0; col < 8; col++ {
+			piece := g.Board.Squares[row][col]
+			if piece.Type == board.Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			sb.WriteString(piece.ASCIIString())
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		if row < 7 {
+			sb.WriteByte('/')
+		}
+	}
+
+	side := "w"
+	if g.CurrentPlayer == board.Black {
+		side = "b"
+	}
+
+	fields := []string{
+		sb.String(),
+		side,
+		g.castlingField(shredder),
+		g.pos.EnPassant().String(),
+		strconv.Itoa(g.pos.HalfMoveClock()),
+		strconv.Itoa(g.pos.FullMoveNumber()),
+	}
+	return strings.Join(g.variant.FormatFEN(&g.pos, fields), " ")
+}
+
+// castlingField returns the castling availability field of the FEN
+func (g *Game) castlingField(shredder bool) string {
+	castling := ""
+	rights := g.pos.Castling()
+	for _, right := range []struct {
+		right  bitboard.CastlingRights
+		symbol rune
+	}{
+		{bitboard.WhiteKingSide, 'K'},
+		{bitboard.WhiteQueenSide, 'Q'},
+		{bitboard.BlackKingSide, 'k'},
+		{bitboard.BlackQueenSide, 'q'},
+	} {
+		if rights&right.right == 0 {
+			continue
+		}
+		symbol := right.symbol
+		rook := g.pos.CastlingRook(right.right)
+		if shredder || rook != outermostRook(&g.pos, right.right) {
+			symbol = 'A' + rune(rook.Col())
+			if unicode.IsLower(right.symbol) {
+				symbol = unicode.ToLower(symbol)
+			}
+		}
+		castling += string(symbol)
+	}
+	if castling == "" {
+		return "-"
+	}
+	return castling
+}
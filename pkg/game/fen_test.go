@@ -0,0 +1,77 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/user/chess/pkg/board"
+)
+
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+		"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 b - - 12 10",
+	}
+
+	for _, fen := range fens {
+		g, err := NewGameFromFEN(fen)
+		if err != nil {
+			t.Errorf("NewGameFromFEN(%q) error = %v", fen, err)
+			continue
+		}
+		if got := g.FEN(); got != fen {
+			t.Errorf("FEN() = %q, want %q", got, fen)
+		}
+	}
+}
+
+func TestFENAfterMoves(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	for _, m := range [][2]string{{"e2", "e4"}, {"c7", "c5"}, {"g1", "f3"}} {
+		if err := g.MakeMove(mustPos(t, m[0]), mustPos(t, m[1])); err != nil {
+			t.Fatalf("MakeMove(%s, %s) failed: %v", m[0], m[1], err)
+		}
+	}
+
+	want := "rnbqkbnr/pp1ppppp/8/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"
+	if got := g.FEN(); got != want {
+		t.Errorf("FEN() = %q, want %q", got, want)
+	}
+}
+
+func TestNewGameFromFENState(t *testing.T) {
+	g, err := NewGameFromFEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.State != Checkmate {
+		t.Errorf("State = %v, want Checkmate", g.State)
+	}
+	if g.CurrentPlayer != board.White {
+		t.Errorf("CurrentPlayer = %v, want White", g.CurrentPlayer)
+	}
+}
+
+func TestNewGameFromFENInvalid(t *testing.T) {
+	fens := []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNRR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e4 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1",
+		"rnbq1bnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQ - 0 1",
+		"4k3/8/8/8/8/8/8/4K2r b - - 0 1",
+	}
+
+	for _, fen := range fens {
+		if _, err := NewGameFromFEN(fen); err == nil {
+			t.Errorf("NewGameFromFEN(%q) expected an error", fen)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// fogOfWar, also called Dark chess, is won by capturing the king. Each
+// player sees only the squares their pieces can move to or attack, so
+// there is no check: a king may move into an attack and stay in one.
+type fogOfWar struct{ standard }
+
+func (fogOfWar) Name() string {
+	return "Fog of War"
+}
+
+func (fogOfWar) Setup(n int) bitboard.Position {
+	p := bitboard.StartPosition()
+	p.SetRules(p.Rules() | bitboard.FogOfWar)
+	return p
+}
+
+func (fogOfWar) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	p, err := parse(fields)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	p.SetRules(p.Rules() | bitboard.FogOfWar)
+	return p, nil
+}
+
+func (fogOfWar) Result(g *Game, result Result) Result {
+	for _, color := range []board.Color{board.White, board.Black} {
+		if g.pos.King(color) == bitboard.NoSquare {
+			return win(opponentOf(color), ReasonKingCaptured)
+		}
+	}
+
+	// A king can walk into a capture, so no material is too little to win
+	return ignoreMaterialDraws(g, result)
+}
+
+// Visible returns the squares the player of the given color can see in Fog
+// of War. In other variants every square is visible.
+func (g *Game) Visible(color board.Color) bitboard.Bitboard {
+	if !g.pos.Rules().Has(bitboard.FogOfWar) {
+		return ^bitboard.Bitboard(0)
+	}
+	return g.pos.Visible(color)
+}
@@ -3,11 +3,15 @@ package game
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/user/chess/pkg/bitboard"
 	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/piece"
 )
 
-// GameState represents the state of a chess game
+// GameState represents the state of a chess game. Once the game is over
+// Result tells who won and why.
 type GameState int
 
 const (
@@ -17,411 +21,336 @@ const (
 	Stalemate
 	Draw
 	TimeOut
+	Finished // Ended by resignation or abandonment, see Result
 )
 
-// Game represents a chess game
+// errGameOver is returned when a move or offer is made after the game ended
+var errGameOver = errors.New("game is already finished")
+
+// errIllegalMove is wrapped by the errors ParseMove returns for moves that
+// are well formed but not legal in the position
+var errIllegalMove = errors.New("illegal move")
+
+// Game represents a chess game. Board and CurrentPlayer mirror the
+// position after every move; changing them does not change the game.
 type Game struct {
-	Board           *board.Board
-	CurrentPlayer   board.Color
-	moveHistory     []Move
-	castlingRights  map[board.Color]CastlingRights
-	enPassantTarget *board.Position
-	halfMoveClock   int // For 50-move rule
-	fullMoveNumber  int
-	State           GameState
-	TimeControl     *TimeControl
+	Board          *board.Board
+	CurrentPlayer  board.Color
+	pos            bitboard.Position // The position the game is played on
+	variant        Variant
+	moveHistory    []Move
+	undoStack      []snapshot  // The state before each move of moveHistory
+	redoStack      []takenBack // Moves taken back, the most recent last
+	positionHashes []uint64    // Hashes of every position of the game, the current one last
+	State          GameState
+	result         Result
+	drawOffer      board.Color // The player whose draw offer is pending
+	TimeControl    *TimeControl
+	WhitePlayer    string
+	BlackPlayer    string
+	startFEN       string // Empty for the standard starting position
+	startTime      time.Time
+	partner        *Game // The other board of a Bughouse match
 }
 
-// Move represents a chess move
+// Move represents a chess move. In Crazyhouse and Bughouse, a move with a
+// Drop type puts a piece of that type from the player's pocket on To, and
+// From is not used.
 type Move struct {
 	From          board.Position
 	To            board.Position
 	PromotionType board.PieceType
+	Drop          board.PieceType
 	Notation      string
 }
 
 // CastlingRights represents the castling rights for a player
-type CastlingRights struct {
-	KingSide  bool
-	QueenSide bool
+type CastlingRights = piece.CastlingRights
+
+// NewGame creates a new chess game. Without options it is a standard game
+// from the usual starting position. It panics if WithStartPosition chose a
+// Chess960 position outside 0 to 959.
+func NewGame(opts ...Option) *Game {
+	o := newOptions(opts)
+	g := &Game{
+		variant:     o.variant,
+		State:       InProgress,
+		TimeControl: NewTimeControl(10, 5), // 10 minutes + 5 seconds increment
+		startTime:   time.Now(),
+	}
+	g.setPosition(o.variant.Setup(o.startPosition))
+	if g.variant == Chess960 {
+		// Chess960 games always record where they started
+		g.startFEN = g.FEN()
+	}
+	g.resetHash()
+	return g
 }
 
-// NewGame creates a new chess game
-func NewGame() *Game {
-	return &Game{
-		Board:         board.NewBoard(),
-		CurrentPlayer: board.White,
-		castlingRights: map[board.Color]CastlingRights{
-			board.White: {KingSide: true, QueenSide: true},
-			board.Black: {KingSide: true, QueenSide: true},
-		},
-		halfMoveClock:  0,
-		fullMoveNumber: 1,
-		State:          InProgress,
-		TimeControl:    NewTimeControl(10, 5), // 10 minutes + 5 seconds increment
-	}
+// Position returns a copy of the current position. Search code can make
+// and unmake moves on it without touching the game.
+func (g *Game) Position() bitboard.Position {
+	return g.pos
 }
 
-// IsValidMove checks if a move is valid
-func (g *Game) IsValidMove(from, to board.Position) bool {
-	// Get the piece at the source position
-	piece := g.Board.GetPiece(from)
-
-	// Check if there's a piece at the source position and it belongs to the current player
-	if piece.Type == board.Empty || piece.Color != g.CurrentPlayer {
-		return false
-	}
-
-	// Check if the destination is occupied by a piece of the same color
-	destPiece := g.Board.GetPiece(to)
-	if destPiece.Type != board.Empty && destPiece.Color == g.CurrentPlayer {
-		return false
-	}
+// setPosition replaces the position and refreshes Board and CurrentPlayer
+func (g *Game) setPosition(p bitboard.Position) {
+	g.pos = p
+	g.Board = p.Board()
+	g.CurrentPlayer = p.SideToMove()
+}
 
-	// Check if the move is valid for the specific piece type
-	return g.isValidPieceMove(from, to, piece)
+// IsValidMove reports whether the current player can legally move the
+// piece on from to to, as PlayMove would accept it. A promotion is valid
+// whatever piece is chosen later.
+func (g *Game) IsValidMove(from, to board.Position) bool {
+	_, ok := g.findLegalMove(from, to, board.Queen)
+	return ok
 }
 
-// isValidPieceMove checks if a move is valid for a specific piece
-func (g *Game) isValidPieceMove(from, to board.Position, piece board.Piece) bool {
-	rowDiff := to.Row - from.Row
-	colDiff := to.Col - from.Col
-	absRowDiff := abs(rowDiff)
-	absColDiff := abs(colDiff)
-
-	switch piece.Type {
-	case board.Pawn:
-		return g.isValidPawnMove(from, to, rowDiff, colDiff, absRowDiff, absColDiff)
-	case board.Knight:
-		// Knights move in an L-shape: 2 squares in one direction and 1 square perpendicular
-		return (absRowDiff == 2 && absColDiff == 1) || (absRowDiff == 1 && absColDiff == 2)
-	case board.Bishop:
-		// Bishops move diagonally
-		if absRowDiff != absColDiff {
-			return false
-		}
-		return g.isClearPath(from, to)
-	case board.Rook:
-		// Rooks move horizontally or vertically
-		if from.Row != to.Row && from.Col != to.Col {
-			return false
-		}
-		return g.isClearPath(from, to)
-	case board.Queen:
-		// Queens move like bishops or rooks
-		if from.Row != to.Row && from.Col != to.Col && absRowDiff != absColDiff {
-			return false
-		}
-		return g.isClearPath(from, to)
-	case board.King:
-		// Check for castling
-		if absRowDiff == 0 && absColDiff == 2 {
-			return g.isValidCastling(from, to)
-		}
-		// Kings move one square in any direction
-		return absRowDiff <= 1 && absColDiff <= 1
-	}
+// MakeMove makes a move on the board and updates the game state.
+// Pawns reaching the last rank are promoted to a queen; use
+// MakeMoveWithPromotion to choose another piece.
+func (g *Game) MakeMove(from, to board.Position) error {
+	return g.MakeMoveWithPromotion(from, to, board.Queen)
+}
 
-	return false
+// MakeMoveWithPromotion makes a move and promotes a pawn reaching the last
+// rank to the given piece type. The promotion type is ignored for moves
+// that are not promotions.
+func (g *Game) MakeMoveWithPromotion(from, to board.Position, promotion board.PieceType) error {
+	return g.PlayMove(Move{From: from, To: to, PromotionType: promotion})
 }
 
-// isValidPawnMove checks if a pawn move is valid
-func (g *Game) isValidPawnMove(from, to board.Position, rowDiff, colDiff, absRowDiff, absColDiff int) bool {
-	// Determine the direction pawns move based on their color
-	forwardDir := -1
-	startRow := 6
-	if g.CurrentPlayer == board.Black {
-		forwardDir = 1
-		startRow = 1
+// PlayMove plays a move such as one returned by LegalMoves or ParseMove.
+// The promotion type is only used when the move is a promotion.
+func (g *Game) PlayMove(m Move) error {
+	if g.IsOver() {
+		return errGameOver
 	}
 
-	// Check for normal pawn move (1 square forward)
-	if colDiff == 0 && rowDiff == forwardDir && g.Board.IsEmpty(to) {
-		return true
+	if g.TimeControl != nil && g.TimeControl.IsTimeUp(g.CurrentPlayer == board.White) {
+		g.timeOut()
+		return fmt.Errorf("time is up for %s", g.GetCurrentPlayerName())
 	}
 
-	// Check for pawn's first move (2 squares forward)
-	if colDiff == 0 && from.Row == startRow && rowDiff == 2*forwardDir {
-		midPos := board.Position{Row: from.Row + forwardDir, Col: from.Col}
-		return g.Board.IsEmpty(midPos) && g.Board.IsEmpty(to)
+	var move Move
+	var ok bool
+	if m.Drop != board.Empty {
+		move, ok = g.findLegalDrop(m.Drop, m.To)
+	} else {
+		move, ok = g.findLegalMove(m.From, m.To, m.PromotionType)
+	}
+	if !ok {
+		return errors.New("invalid move")
 	}
 
-	// Check for capture (1 square diagonally)
-	if absColDiff == 1 && rowDiff == forwardDir {
-		// Normal capture
-		if !g.Board.IsEmpty(to) && g.Board.GetPiece(to).Color != g.CurrentPlayer {
-			return true
-		}
+	move.Notation = g.san(move)
+	before := g.snapshot()
+	g.passCaptured(move)
 
-		// En passant capture
-		if g.enPassantTarget != nil && to.Row == g.enPassantTarget.Row && to.Col == g.enPassantTarget.Col {
-			return true
-		}
+	// Update time control
+	if g.TimeControl != nil {
+		g.TimeControl.SwitchPlayer(g.CurrentPlayer == board.White)
 	}
 
-	return false
+	g.playMove(move)
+	g.undoStack = append(g.undoStack, before)
+	g.redoStack = nil
+	return nil
 }
 
-// isValidCastling checks if a castling move is valid
-func (g *Game) isValidCastling(from, to board.Position) bool {
-	// Check if castling rights are available
-	rights := g.castlingRights[g.CurrentPlayer]
+// playMove plays a legal move and updates castling rights, the en passant
+// target, the move clocks, the side to move and the game state
+func (g *Game) playMove(move Move) {
+	g.makeMove(move)
+	g.moveHistory = append(g.moveHistory, move)
+	g.updateGameState()
+}
 
-	// Determine if it's kingside or queenside castling
-	var rookCol int
-	if to.Col > from.Col { // Kingside
-		if !rights.KingSide {
-			return false
-		}
-		rookCol = 7
-	} else { // Queenside
-		if !rights.QueenSide {
-			return false
-		}
-		rookCol = 0
+// makeMove applies a legal move to the position and adds the new position
+// to the repetition history. It leaves the move history and game state
+// alone.
+func (g *Game) makeMove(move Move) {
+	// Making a move declines the opponent's draw offer
+	if g.drawOffer == g.opponent() {
+		g.drawOffer = board.NoColor
 	}
 
-	rookRow := from.Row
-	rookPos := board.Position{Row: rookRow, Col: rookCol}
-
-	// Check if there are pieces between the king and the rook
-	return g.isClearPath(from, rookPos) && !g.isInCheck(g.CurrentPlayer)
+	p := g.pos
+	p.MakeMove(toBitboardMove(move))
+	g.setPosition(p)
+	g.positionHashes = append(g.positionHashes, g.pos.Hash())
 }
 
-// isClearPath checks if there are no pieces between the start and end positions
-func (g *Game) isClearPath(from, to board.Position) bool {
-	// Determine the direction
-	rowDir := 0
-	if to.Row > from.Row {
-		rowDir = 1
-	} else if to.Row < from.Row {
-		rowDir = -1
-	}
+// clone returns a copy of the game that can be played on without affecting
+// the original. The copy has no time control and no Bughouse partner.
+func (g *Game) clone() *Game {
+	c := *g
+	c.TimeControl = nil
+	c.partner = nil
+	// Force appends on the copy to allocate a new backing array
+	c.moveHistory = g.moveHistory[:len(g.moveHistory):len(g.moveHistory)]
+	c.positionHashes = g.positionHashes[:len(g.positionHashes):len(g.positionHashes)]
+	return &c
+}
 
-	colDir := 0
-	if to.Col > from.Col {
-		colDir = 1
-	} else if to.Col < from.Col {
-		colDir = -1
-	}
+// SetPlayerNames sets the names of the players
+func (g *Game) SetPlayerNames(white, black string) {
+	g.WhitePlayer = white
+	g.BlackPlayer = black
+}
 
-	row, col := from.Row+rowDir, from.Col+colDir
-	for {
-		if row == to.Row && col == to.Col {
-			break
-		}
+// isEnPassant reports whether the move is an en passant capture
+func (g *Game) isEnPassant(m Move) bool {
+	return m.Drop == board.Empty && g.Board.GetPiece(m.From).Type == board.Pawn &&
+		bitboard.SquareOf(m.To) == g.pos.EnPassant()
+}
 
-		if !g.Board.IsEmpty(board.Position{Row: row, Col: col}) {
-			return false
-		}
+// castlingRight returns the castling right a legal move uses, or
+// NoCastling if it does not castle
+func (g *Game) castlingRight(m Move) bitboard.CastlingRights {
+	return g.pos.CastlingRight(toBitboardMove(m))
+}
 
-		row += rowDir
-		col += colDir
+// LegalMoves returns every legal move for the current player.
+// Pawn moves to the last rank are returned once per promotion piece.
+func (g *Game) LegalMoves() []Move {
+	p := g.Position()
+	legal := g.legalMoves(&p)
+	moves := make([]Move, len(legal))
+	for i, m := range legal {
+		moves[i] = fromBitboardMove(m)
 	}
-
-	return true
+	return moves
 }
 
-// MakeMove makes a move on the board and updates the game state
-func (g *Game) MakeMove(from, to board.Position) error {
-	if g.State != InProgress {
-		return fmt.Errorf("game is already finished")
-	}
+// legalMoves returns the moves the variant allows in p
+func (g *Game) legalMoves(p *bitboard.Position) []bitboard.Move {
+	return g.variant.LegalMoves(p)
+}
 
-	if g.TimeControl != nil && g.TimeControl.IsTimeUp(g.CurrentPlayer == board.White) {
-		g.State = TimeOut
-		return fmt.Errorf("time is up for %s", g.GetCurrentPlayerName())
+// LegalMovesFrom returns the legal moves of the current player's piece at pos
+func (g *Game) LegalMovesFrom(pos board.Position) []Move {
+	var moves []Move
+	for _, move := range g.LegalMoves() {
+		if move.From == pos && move.Drop == board.Empty {
+			moves = append(moves, move)
+		}
 	}
+	return moves
+}
 
-	if !g.IsValidMove(from, to) {
-		return errors.New("invalid move")
+// toBitboardMove converts a move to the bitboard package's representation
+func toBitboardMove(m Move) bitboard.Move {
+	if m.Drop != board.Empty {
+		return bitboard.Move{From: bitboard.NoSquare, To: bitboard.SquareOf(m.To), Drop: m.Drop}
 	}
-
-	piece := g.Board.GetPiece(from)
-	capturedPiece := g.Board.GetPiece(to)
-
-	// Handle en passant capture
-	if piece.Type == board.Pawn && g.enPassantTarget != nil && to.Row == g.enPassantTarget.Row && to.Col == g.enPassantTarget.Col {
-		capturePos := board.Position{Row: from.Row, Col: to.Col}
-		g.Board.SetPiece(capturePos, board.Piece{Type: board.Empty, Color: board.NoColor})
+	return bitboard.Move{
+		From:      bitboard.SquareOf(m.From),
+		To:        bitboard.SquareOf(m.To),
+		Promotion: m.PromotionType,
 	}
+}
 
-	// Update en passant target
-	g.enPassantTarget = nil
-	if piece.Type == board.Pawn && abs(to.Row-from.Row) == 2 {
-		enPassantRow := (from.Row + to.Row) / 2
-		g.enPassantTarget = &board.Position{Row: enPassantRow, Col: from.Col}
+// fromBitboardMove converts a move from the bitboard package's representation
+func fromBitboardMove(m bitboard.Move) Move {
+	if m.Drop != board.Empty {
+		return Move{To: m.To.Position(), Drop: m.Drop}
 	}
+	return Move{From: m.From.Position(), To: m.To.Position(), PromotionType: m.Promotion}
+}
 
-	// Handle castling
-	if piece.Type == board.King && abs(to.Col-from.Col) == 2 {
-		rookRow := from.Row
-		var oldRookCol, newRookCol int
-		if to.Col > from.Col { // Kingside
-			oldRookCol = 7
-			newRookCol = 5
-		} else { // Queenside
-			oldRookCol = 0
-			newRookCol = 3
+// promotionTypes lists the pieces a pawn may promote to
+var promotionTypes = []board.PieceType{board.Queen, board.Rook, board.Bishop, board.Knight}
+
+// findLegalMove looks up the legal move from one square to another.
+// The promotion piece is only used when the move is a promotion. In
+// Chess960, where castling moves the king onto its rook, castling may also
+// be given as the king's move to its destination, unless the king can go
+// there without castling.
+func (g *Game) findLegalMove(from, to board.Position, promotion board.PieceType) (Move, bool) {
+	moves := g.LegalMovesFrom(from)
+	for _, move := range moves {
+		if move.To != to {
+			continue
 		}
-		rookFrom := board.Position{Row: rookRow, Col: oldRookCol}
-		rookTo := board.Position{Row: rookRow, Col: newRookCol}
-		g.Board.MovePiece(rookFrom, rookTo)
-	}
-
-	// Update castling rights
-	if piece.Type == board.King {
-		g.castlingRights[g.CurrentPlayer] = CastlingRights{false, false}
-	} else if piece.Type == board.Rook {
-		rights := g.castlingRights[g.CurrentPlayer]
-		if from.Col == 0 {
-			rights.QueenSide = false
-		} else if from.Col == 7 {
-			rights.KingSide = false
+		if move.PromotionType == board.Empty || move.PromotionType == promotion {
+			return move, true
 		}
-		g.castlingRights[g.CurrentPlayer] = rights
-	}
-
-	// Make the move
-	g.Board.MovePiece(from, to)
-
-	// Handle pawn promotion (default to Queen)
-	if piece.Type == board.Pawn && (to.Row == 0 || to.Row == 7) {
-		g.Board.SetPiece(to, board.Piece{Type: board.Queen, Color: g.CurrentPlayer})
 	}
 
-	// Update half-move clock
-	if piece.Type == board.Pawn || capturedPiece.Type != board.Empty {
-		g.halfMoveClock = 0
-	} else {
-		g.halfMoveClock++
-	}
-
-	// Update time control
-	if g.TimeControl != nil {
-		g.TimeControl.SwitchPlayer(g.CurrentPlayer == board.White)
+	if g.pos.Rules().Has(bitboard.Chess960) {
+		for _, move := range moves {
+			right := g.castlingRight(move)
+			if right == bitboard.NoCastling {
+				continue
+			}
+			if kingTo, _ := bitboard.CastlingTargets(right, g.CurrentPlayer); kingTo == bitboard.SquareOf(to) {
+				return move, true
+			}
+		}
 	}
+	return Move{}, false
+}
 
-	// Switch player and update game state
-	if g.CurrentPlayer == board.White {
-		g.CurrentPlayer = board.Black
-	} else {
-		g.CurrentPlayer = board.White
-		g.fullMoveNumber++
+// findLegalDrop looks up the legal drop of a piece type from the pocket
+func (g *Game) findLegalDrop(pieceType board.PieceType, to board.Position) (Move, bool) {
+	for _, move := range g.LegalMoves() {
+		if move.Drop == pieceType && move.To == to {
+			return move, true
+		}
 	}
-
-	g.updateGameState()
-	return nil
+	return Move{}, false
 }
 
 // updateGameState updates the state of the game (check, checkmate, etc.)
 func (g *Game) updateGameState() {
-	// Check if the current player is in check
-	inCheck := g.isInCheck(g.CurrentPlayer)
-
-	// Check if the current player has any valid moves
-	hasValidMoves := g.hasValidMoves()
+	inCheck := g.pos.InCheck(g.CurrentPlayer)
 
-	if inCheck && !hasValidMoves {
-		g.State = Checkmate
+	g.result = Result{}
+	if result := g.variant.Result(g, g.standardResult(inCheck)); result.IsOver() {
+		g.finish(result)
 	} else if inCheck {
 		g.State = Check
-	} else if !hasValidMoves {
-		g.State = Stalemate
-	} else if g.halfMoveClock >= 50 {
-		g.State = Draw // 50-move rule
 	} else {
 		g.State = InProgress
 	}
 }
 
-// isInCheck checks if a player is in check
-func (g *Game) isInCheck(color board.Color) bool {
-	// Find the king
-	var kingPos board.Position
-	found := false
-	for row := 0; row < 8; row++ {
-		for col := 0; col < 8; col++ {
-			pos := board.Position{Row: row, Col: col}
-			piece := g.Board.GetPiece(pos)
-			if piece.Type == board.King && piece.Color == color {
-				kingPos = pos
-				found = true
-				break
-			}
-		}
-		if found {
-			break
-		}
-	}
-
-	// Check if any opponent piece can capture the king
-	opponentColor := board.White
-	if color == board.White {
-		opponentColor = board.Black
-	}
-
-	for row := 0; row < 8; row++ {
-		for col := 0; col < 8; col++ {
-			pos := board.Position{Row: row, Col: col}
-			piece := g.Board.GetPiece(pos)
-			if piece.Type != board.Empty && piece.Color == opponentColor {
-				// Temporarily switch player to check move validity
-				savedPlayer := g.CurrentPlayer
-				g.CurrentPlayer = opponentColor
-				isValid := g.isValidPieceMove(pos, kingPos, piece)
-				g.CurrentPlayer = savedPlayer
-				if isValid {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
+// standardResult returns how the standard rules end the game in the
+// current position, or a Result with an Ongoing outcome. Positions where
+// mate is impossible, fivefold repetition and the 75-move rule end the
+// game without a claim, unless the last move delivered mate or stalemate.
+func (g *Game) standardResult(inCheck bool) Result {
+	p := g.Position()
+	hasValidMoves := len(g.legalMoves(&p)) > 0
+
+	switch {
+	case inCheck && !hasValidMoves:
+		return win(g.opponent(), ReasonCheckmate)
+	case !hasValidMoves:
+		return draw(ReasonStalemate)
+	case g.hasInsufficientMaterial():
+		return draw(ReasonInsufficientMaterial)
+	case g.isDeadPosition():
+		return draw(ReasonDeadPosition)
+	case g.RepetitionCount() >= 5:
+		return draw(ReasonFivefoldRepetition)
+	case g.pos.HalfMoveClock() >= 150:
+		return draw(ReasonSeventyFiveMoves)
+	}
+	return Result{}
 }
 
-// hasValidMoves checks if the current player has any valid moves
-func (g *Game) hasValidMoves() bool {
-	for fromRow := 0; fromRow < 8; fromRow++ {
-		for fromCol := 0; fromCol < 8; fromCol++ {
-			fromPos := board.Position{Row: fromRow, Col: fromCol}
-			piece := g.Board.GetPiece(fromPos)
-			if piece.Type != board.Empty && piece.Color == g.CurrentPlayer {
-				for toRow := 0; toRow < 8; toRow++ {
-					for toCol := 0; toCol < 8; toCol++ {
-						toPos := board.Position{Row: toRow, Col: toCol}
-						if g.IsValidMove(fromPos, toPos) {
-							// Check if the move would leave the player in check
-							if !g.wouldBeInCheck(fromPos, toPos) {
-								return true
-							}
-						}
-					}
-				}
-			}
-		}
+// CanClaimDraw reports whether the player to move may claim a draw, either
+// because the position has occurred three times or because no pawn has
+// moved and nothing has been captured in the last 50 moves
+func (g *Game) CanClaimDraw() bool {
+	if g.IsOver() {
+		return false
 	}
-	return false
-}
-
-// wouldBeInCheck checks if a move would leave the player in check
-func (g *Game) wouldBeInCheck(from, to board.Position) bool {
-	// Save the current state
-	origPiece := g.Board.GetPiece(from)
-	destPiece := g.Board.GetPiece(to)
-
-	// Make the move temporarily
-	g.Board.MovePiece(from, to)
-
-	// Check if the player is in check
-	inCheck := g.isInCheck(g.CurrentPlayer)
-
-	// Restore the board
-	g.Board.SetPiece(from, origPiece)
-	g.Board.SetPiece(to, destPiece)
-
-	return inCheck
+	return g.RepetitionCount() >= 3 || g.pos.HalfMoveClock() >= 100
 }
 
 // GetGameStatus returns a string representation of the game status
@@ -437,19 +366,8 @@ func (g *Game) GetGameStatus() string {
 			return "White is in check"
 		}
 		return "Black is in check"
-	case Checkmate:
-		if g.CurrentPlayer == board.White {
-			return "Black wins by checkmate"
-		}
-		return "White wins by checkmate"
-	case Stalemate:
-		return "Draw by stalemate"
-	case Draw:
-		return "Draw"
-	case TimeOut:
-		return "Time out"
 	default:
-		return "Unknown game state"
+		return g.result.Description()
 	}
 }
 
@@ -469,6 +387,19 @@ func (g *Game) GetCurrentPlayerName() string {
 	return "Black"
 }
 
+// opponent returns the color of the player who is not to move
+func (g *Game) opponent() board.Color {
+	return opponentOf(g.CurrentPlayer)
+}
+
+// opponentOf returns the other color
+func opponentOf(color board.Color) board.Color {
+	if color == board.White {
+		return board.Black
+	}
+	return board.White
+}
+
 // Utility function
 func abs(x int) int {
 	if x < 0 {
@@ -0,0 +1,200 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// emptyGame returns a game with an empty board and no castling rights
+func emptyGame() *Game {
+	g := NewGame()
+	g.setPosition(bitboard.NewPosition(&board.Board{}, board.White))
+	return g
+}
+
+func mustPos(t *testing.T, algebraic string) board.Position {
+	t.Helper()
+	pos, err := board.NewPosition(algebraic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pos
+}
+
+func place(t *testing.T, g *Game, algebraic string, pieceType board.PieceType, color board.Color) {
+	t.Helper()
+	b := g.pos.Board()
+	b.SetPiece(mustPos(t, algebraic), board.Piece{Type: pieceType, Color: color})
+	g.setPosition(bitboard.NewPosition(b, g.CurrentPlayer))
+}
+
+// playMoves plays moves given in SAN or as squares, as in "Nf3" or "g1 f3"
+func playMoves(t *testing.T, g *Game, moves ...string) {
+	t.Helper()
+	for _, text := range moves {
+		move, err := g.ParseMove(text)
+		if err != nil {
+			t.Fatalf("ParseMove(%q) failed: %v", text, err)
+		}
+		if err := g.PlayMove(move); err != nil {
+			t.Fatalf("PlayMove(%q) failed: %v", text, err)
+		}
+	}
+}
+
+func TestLegalMovesInitialPosition(t *testing.T) {
+	g := NewGame()
+	if got := len(g.LegalMoves()); got != 20 {
+		t.Errorf("LegalMoves() returned %d moves, want 20", got)
+	}
+}
+
+func TestLegalMovesPinnedPiece(t *testing.T) {
+	g := emptyGame()
+	place(t, g, "e1", board.King, board.White)
+	place(t, g, "e2", board.Rook, board.White)
+	place(t, g, "e8", board.Rook, board.Black)
+	place(t, g, "a8", board.King, board.Black)
+
+	for _, move := range g.LegalMovesFrom(mustPos(t, "e2")) {
+		if move.To.Col != 4 {
+			t.Errorf("pinned rook may not leave the e-file, got %v", move.To)
+		}
+	}
+
+	if err := g.MakeMove(mustPos(t, "e2"), mustPos(t, "d2")); err == nil {
+		t.Error("MakeMove allowed a move that exposes the king")
+	}
+}
+
+func TestLegalMovesEnPassantDiscoveredCheck(t *testing.T) {
+	g := emptyGame()
+	place(t, g, "a5", board.King, board.White)
+	place(t, g, "e5", board.Pawn, board.White)
+	place(t, g, "d5", board.Pawn, board.Black)
+	place(t, g, "h5", board.Rook, board.Black)
+	place(t, g, "h8", board.King, board.Black)
+	g.pos.SetEnPassant(bitboard.SquareOf(mustPos(t, "d6")))
+
+	for _, move := range g.LegalMovesFrom(mustPos(t, "e5")) {
+		if move.To == mustPos(t, "d6") {
+			t.Error("en passant capture exposing the king was reported as legal")
+		}
+	}
+}
+
+func TestLegalMovesPromotion(t *testing.T) {
+	g := emptyGame()
+	place(t, g, "e1", board.King, board.White)
+	place(t, g, "b7", board.Pawn, board.White)
+	place(t, g, "h8", board.King, board.Black)
+
+	moves := g.LegalMovesFrom(mustPos(t, "b7"))
+	if len(moves) != 4 {
+		t.Fatalf("LegalMovesFrom(b7) returned %d moves, want 4", len(moves))
+	}
+	seen := make(map[board.PieceType]bool)
+	for _, move := range moves {
+		seen[move.PromotionType] = true
+	}
+	for _, promotion := range promotionTypes {
+		if !seen[promotion] {
+			t.Errorf("missing promotion to %v", promotion)
+		}
+	}
+}
+
+func TestMakeMoveOutOfCheck(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	moves := [][2]string{{"e2", "e4"}, {"f7", "f6"}, {"d1", "h5"}}
+	for _, m := range moves {
+		if err := g.MakeMove(mustPos(t, m[0]), mustPos(t, m[1])); err != nil {
+			t.Fatalf("MakeMove(%s, %s) failed: %v", m[0], m[1], err)
+		}
+	}
+	if g.State != Check {
+		t.Fatalf("State = %v, want Check", g.State)
+	}
+	if err := g.MakeMove(mustPos(t, "a7"), mustPos(t, "a6")); err == nil {
+		t.Error("MakeMove ignored the check")
+	}
+	if err := g.MakeMove(mustPos(t, "g7"), mustPos(t, "g6")); err != nil {
+		t.Errorf("MakeMove(g7, g6) failed: %v", err)
+	}
+}
+
+func TestUnderpromotionMate(t *testing.T) {
+	g, err := NewGameFromFEN("8/6Pp/6pk/6pp/8/8/8/B1K5 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+
+	if err := g.MakeMoveWithPromotion(mustPos(t, "g7"), mustPos(t, "g8"), board.Knight); err != nil {
+		t.Fatal(err)
+	}
+	if promoted := g.Board.GetPiece(mustPos(t, "g8")); promoted.Type != board.Knight {
+		t.Errorf("promoted to %v, want a knight", promoted.Type)
+	}
+	if g.State != Checkmate {
+		t.Errorf("State = %v, want Checkmate", g.State)
+	}
+	if got := g.moveHistory[0].Notation; got != "g8=N#" {
+		t.Errorf("Notation = %q, want g8=N#", got)
+	}
+}
+
+func TestSaveLoadKeepsPromotion(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	playMoves(t, g, "h2 h4", "g7 g5", "h4 g5", "f8 g7", "g5 g6", "e7 e6", "g6 h7", "e8 f8")
+	if err := g.MakeMoveWithPromotion(mustPos(t, "h7"), mustPos(t, "g8"), board.Rook); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "game.json")
+	if err := g.SaveGame(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewGame()
+	if err := loaded.LoadGame(filename); err != nil {
+		t.Fatal(err)
+	}
+	if promoted := loaded.Board.GetPiece(mustPos(t, "g8")); promoted.Type != board.Rook {
+		t.Errorf("loaded game promoted to %v, want a rook", promoted.Type)
+	}
+	if loaded.FEN() != g.FEN() {
+		t.Errorf("loaded FEN = %q, want %q", loaded.FEN(), g.FEN())
+	}
+}
+
+func TestPositionIsACopy(t *testing.T) {
+	// Castling, en passant and promotions are all available here
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	g, err := NewGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := g.Position()
+	for _, m := range p.LegalMoves() {
+		undo := p.MakeMove(m)
+		for _, reply := range p.LegalMoves() {
+			replyUndo := p.MakeMove(reply)
+			p.UnmakeMove(reply, replyUndo)
+		}
+		p.UnmakeMove(m, undo)
+		if p != g.Position() {
+			t.Fatalf("position differs after making and unmaking %v", m)
+		}
+	}
+
+	p.MakeMove(p.LegalMoves()[0])
+	if got := g.FEN(); got != fen {
+		t.Errorf("playing on the copy changed the game to %s", got)
+	}
+}
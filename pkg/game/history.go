@@ -3,35 +3,62 @@ package game
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/pgn"
 )
 
 // GameHistory represents a complete game with its moves
 type GameHistory struct {
 	Date        time.Time `json:"date"`
 	Moves       []string  `json:"moves"`
-	Result      string    `json:"result"`
+	Result      string    `json:"result"`                // The outcome, such as "1-0" or "*"
+	Termination string    `json:"termination,omitempty"` // Why the game ended
+	Variant     string    `json:"variant,omitempty"`     // Empty for standard chess
+	StartFEN    string    `json:"start_fen,omitempty"`   // Empty for the standard starting position
 	WhitePlayer string    `json:"white_player"`
 	BlackPlayer string    `json:"black_player"`
 }
 
-// SaveGame saves the current game to a JSON file
+// SaveGame saves the current game to a file. Files with a .pgn extension
+// are written in PGN, all others as JSON.
 func (g *Game) SaveGame(filename string) error {
-	// Convert []Move to []string
+	if strings.EqualFold(filepath.Ext(filename), ".pgn") {
+		return g.savePGN(filename)
+	}
+
+	// Convert []Move to []string, adding the piece chosen for promotions.
+	// Drops are written as "N@f3".
 	moveStrings := make([]string, len(g.moveHistory))
 	for i, move := range g.moveHistory {
+		if move.Drop != board.Empty {
+			moveStrings[i] = dropNotation(move)
+			continue
+		}
 		moveStrings[i] = fmt.Sprintf("%s %s", move.From.String(), move.To.String())
+		if move.PromotionType != board.Empty {
+			promoted := board.Piece{Type: move.PromotionType, Color: board.Black}
+			moveStrings[i] += " " + promoted.ASCIIString()
+		}
 	}
 
 	history := GameHistory{
 		Date:        time.Now(),
 		Moves:       moveStrings,
-		Result:      g.GetGameStatus(),
-		WhitePlayer: "Player 1",
-		BlackPlayer: "Player 2",
+		Result:      g.result.Outcome.String(),
+		Termination: g.result.Reason.String(),
+		StartFEN:    g.startFEN,
+		WhitePlayer: g.WhitePlayer,
+		BlackPlayer: g.BlackPlayer,
+	}
+
+	if g.variant != Standard {
+		history.Variant = g.variant.Name()
 	}
 
 	data, err := json.MarshalIndent(history, "", "  ")
@@ -47,8 +74,28 @@ func (g *Game) SaveGame(filename string) error {
 	return nil
 }
 
-// LoadGame loads a game from a JSON file
+// savePGN writes the current game to a PGN file
+func (g *Game) savePGN(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error saving game history: %v", err)
+	}
+	defer file.Close()
+
+	if err := g.WritePGN(file, nil); err != nil {
+		return fmt.Errorf("error saving game history: %v", err)
+	}
+
+	return file.Close()
+}
+
+// LoadGame loads a game from a JSON or PGN file. For PGN files the first
+// game of the file is loaded.
 func (g *Game) LoadGame(filename string) error {
+	if strings.EqualFold(filepath.Ext(filename), ".pgn") {
+		return g.LoadPGN(filename, 1)
+	}
+
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("error reading game file: %v", err)
@@ -60,33 +107,112 @@ func (g *Game) LoadGame(filename string) error {
 		return fmt.Errorf("error unmarshaling game history: %v", err)
 	}
 
-	// Reset the game
-	g.Board = board.NewBoard()
-	g.CurrentPlayer = board.White
-	g.State = InProgress
-	g.moveHistory = make([]Move, 0)
+	variant, err := ParseVariant(history.Variant)
+	if err != nil {
+		return err
+	}
+
+	// Replay all moves on a fresh game without running the clock
+	replayed, err := newGameFrom(variant, history.StartFEN)
+	if err != nil {
+		return fmt.Errorf("invalid start position: %v", err)
+	}
+	replayed.TimeControl = nil
+	replayed.SetPlayerNames(history.WhitePlayer, history.BlackPlayer)
+	replayed.startTime = history.Date
 
-	// Replay all moves
 	for _, moveStr := range history.Moves {
 		// Parse and apply each move
-		var from, to string
-		fmt.Sscanf(moveStr, "%s %s", &from, &to)
+		fields := strings.Fields(moveStr)
+		if len(fields) == 1 && strings.Contains(moveStr, "@") {
+			move, err := replayed.ParseMove(moveStr)
+			if err == nil {
+				err = replayed.PlayMove(move)
+			}
+			if err != nil {
+				return fmt.Errorf("error replaying move %s: %v", moveStr, err)
+			}
+			continue
+		}
+		if len(fields) != 2 && len(fields) != 3 {
+			return fmt.Errorf("invalid move in history %s", moveStr)
+		}
 
-		fromPos, err := board.NewPosition(from)
+		fromPos, err := board.NewPosition(fields[0])
 		if err != nil {
 			return fmt.Errorf("invalid move in history %s: %v", moveStr, err)
 		}
 
-		toPos, err := board.NewPosition(to)
+		toPos, err := board.NewPosition(fields[1])
 		if err != nil {
 			return fmt.Errorf("invalid move in history %s: %v", moveStr, err)
 		}
 
-		err = g.MakeMove(fromPos, toPos)
+		// Older files have no promotion piece and always promoted to a queen
+		promotion := board.Queen
+		if len(fields) == 3 {
+			promoted, err := board.ParsePiece(rune(fields[2][0]))
+			if err != nil || len(fields[2]) != 1 {
+				return fmt.Errorf("invalid promotion in history %s", moveStr)
+			}
+			promotion = promoted.Type
+		}
+
+		err = replayed.MakeMoveWithPromotion(fromPos, toPos, promotion)
 		if err != nil {
 			return fmt.Errorf("error replaying move %s: %v", moveStr, err)
 		}
 	}
 
+	// Restore results the moves do not decide, such as a resignation.
+	// Older files stored a status message here, which is ignored.
+	outcome, ok := parseOutcome(history.Result)
+	reason, known := parseReason(history.Termination)
+	if ok && known && outcome != Ongoing && !replayed.IsOver() {
+		replayed.finish(Result{Outcome: outcome, Reason: reason})
+	}
+
+	g.replace(replayed)
 	return nil
 }
+
+// LoadPGN loads the n-th game of a PGN file, counting from 1
+func (g *Game) LoadPGN(filename string, n int) error {
+	if n < 1 {
+		return fmt.Errorf("invalid game number %d", n)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("error reading game file: %v", err)
+	}
+	defer file.Close()
+
+	parser := pgn.NewParser(file)
+	for i := 1; ; i++ {
+		pg, err := parser.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s contains %d games, cannot load game %d", filename, i-1, n)
+		}
+		if err != nil {
+			return fmt.Errorf("error parsing game file: %v", err)
+		}
+		if i < n {
+			continue
+		}
+
+		replayed, err := NewGameFromPGN(pg)
+		if err != nil {
+			return err
+		}
+		g.replace(replayed)
+		return nil
+	}
+}
+
+// replace takes over the state of another game, keeping the time control
+func (g *Game) replace(other *Game) {
+	timeControl := g.TimeControl
+	*g = *other
+	g.TimeControl = timeControl
+}
@@ -0,0 +1,41 @@
+package game
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// horde pits Black's usual army against 36 White pawns and no White king.
+// Black wins by capturing every White piece, White by checkmate.
+type horde struct{ standard }
+
+func (horde) Name() string {
+	return "Horde"
+}
+
+func (horde) Setup(n int) bitboard.Position {
+	p := bitboard.NewPosition(board.NewHordeBoard(), board.White)
+	p.SetRules(p.Rules() | bitboard.Horde)
+	p.SetCastling(bitboard.BlackKingSide | bitboard.BlackQueenSide)
+	return p
+}
+
+// ParseFEN switches on the Horde pawn rules, which also let the FEN have
+// White pawns on the first rank and no White king
+func (horde) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	p, err := parse(fields)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	p.SetRules(p.Rules() | bitboard.Horde)
+	return p, nil
+}
+
+func (horde) Result(g *Game, result Result) Result {
+	if g.pos.Color(board.White) == 0 {
+		return win(board.Black, ReasonHordeDestroyed)
+	}
+
+	// Black can still win by taking the last pawns, and pawns can promote
+	return ignoreMaterialDraws(g, result)
+}
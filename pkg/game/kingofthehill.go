@@ -0,0 +1,29 @@
+package game
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// kingOfTheHill is won by checkmate or by bringing the king to one of the
+// four center squares
+type kingOfTheHill struct{ standard }
+
+// hill holds the center squares d4, e4, d5 and e5
+var hill = bitboard.SquareAt(3, 3).Bitboard() | bitboard.SquareAt(3, 4).Bitboard() |
+	bitboard.SquareAt(4, 3).Bitboard() | bitboard.SquareAt(4, 4).Bitboard()
+
+func (kingOfTheHill) Name() string {
+	return "King of the Hill"
+}
+
+func (kingOfTheHill) Result(g *Game, result Result) Result {
+	for _, color := range []board.Color{board.White, board.Black} {
+		if king := g.pos.King(color); king != bitboard.NoSquare && hill.Has(king) {
+			return win(color, ReasonKingOfTheHill)
+		}
+	}
+
+	// A lone king can still win by walking to the center
+	return ignoreMaterialDraws(g, result)
+}
@@ -0,0 +1,220 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// kriegspiel is played by the standard rules, but each player sees only
+// their own pieces. A referee, who sees the whole board, tells the players
+// whether their moves are legal and announces captures, checks and pawn
+// captures to both of them.
+type kriegspiel struct{ standard }
+
+func (kriegspiel) Name() string {
+	return "Kriegspiel"
+}
+
+// AnnouncementKind is what the Kriegspiel referee announces
+type AnnouncementKind int
+
+const (
+	Illegal              AnnouncementKind = iota // The move tried is not legal
+	PawnCaptured                                 // A pawn was captured on Square
+	PieceCaptured                                // A piece other than a pawn was captured on Square
+	CheckOnRank                                  // The king is in check along its rank
+	CheckOnFile                                  // The king is in check along its file
+	CheckOnLongDiagonal                          // The king is in check along the longer of its diagonals
+	CheckOnShortDiagonal                         // The king is in check along the shorter of its diagonals
+	KnightCheck                                  // The king is in check from a knight
+	PawnTries                                    // The player to move has Count pawn captures
+)
+
+// Announcement is something the Kriegspiel referee says to both players.
+// Color is the player it concerns: the one who tried an illegal move, lost
+// a piece, is in check or has pawn captures.
+type Announcement struct {
+	Kind   AnnouncementKind
+	Color  board.Color
+	Square board.Position // The square of a capture
+	Count  int            // The number of pawn captures
+}
+
+// String returns the announcement as the referee says it, such as "Pawn
+// captured on e5" or "Check on the long diagonal"
+func (a Announcement) String() string {
+	switch a.Kind {
+	case Illegal:
+		return "Illegal"
+	case PawnCaptured:
+		return fmt.Sprintf("Pawn captured on %s", a.Square)
+	case PieceCaptured:
+		return fmt.Sprintf("Piece captured on %s", a.Square)
+	case CheckOnRank:
+		return "Check on the rank"
+	case CheckOnFile:
+		return "Check on the file"
+	case CheckOnLongDiagonal:
+		return "Check on the long diagonal"
+	case CheckOnShortDiagonal:
+		return "Check on the short diagonal"
+	case KnightCheck:
+		return "Knight check"
+	case PawnTries:
+		if a.Count == 1 {
+			return "1 pawn try"
+		}
+		return fmt.Sprintf("%d pawn tries", a.Count)
+	}
+	return "Unknown announcement"
+}
+
+// Referee judges a game of Kriegspiel. Players try moves through the
+// referee instead of playing them on the game, and see the board through
+// Board.View. Every announcement is kept in a log that both players can
+// read, so a front end only has to show each player the part they missed.
+type Referee struct {
+	game *Game
+	log  []Announcement
+}
+
+// NewReferee creates a referee for a game. Announcements start with the
+// position the game is in.
+func NewReferee(g *Game) *Referee {
+	r := &Referee{game: g}
+	r.log = append(r.log, r.turnAnnouncements()...)
+	return r
+}
+
+// Attempt tries a move for the player to move, written in any notation
+// ParseMove accepts. A legal move is played and the announcements it
+// causes are returned: the capture, the checks to the opponent and the
+// opponent's pawn tries. An illegal move is announced as Illegal and
+// leaves the player to try another. Text that is not a move or is
+// ambiguous returns an error without an announcement, as does a move made
+// after the game ended or the clock ran out.
+func (r *Referee) Attempt(text string) ([]Announcement, error) {
+	g := r.game
+	if g.IsOver() {
+		return nil, errGameOver
+	}
+
+	move, err := g.ParseMove(text)
+	if errors.Is(err, errIllegalMove) {
+		illegal := []Announcement{{Kind: Illegal, Color: g.CurrentPlayer}}
+		r.log = append(r.log, illegal...)
+		return illegal, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	captured, square := g.capturedBy(move)
+	if err := g.PlayMove(move); err != nil {
+		return nil, err
+	}
+
+	var announcements []Announcement
+	switch captured.Type {
+	case board.Empty:
+	case board.Pawn:
+		announcements = append(announcements, Announcement{Kind: PawnCaptured, Color: captured.Color, Square: square})
+	default:
+		announcements = append(announcements, Announcement{Kind: PieceCaptured, Color: captured.Color, Square: square})
+	}
+	if !g.IsOver() || g.State == Checkmate {
+		announcements = append(announcements, r.turnAnnouncements()...)
+	}
+	r.log = append(r.log, announcements...)
+	return announcements, nil
+}
+
+// Log returns every announcement made in the game, oldest first
+func (r *Referee) Log() []Announcement {
+	return append([]Announcement(nil), r.log...)
+}
+
+// turnAnnouncements returns the checks to the player to move and, unless
+// the game is over, the number of pawn captures they can make
+func (r *Referee) turnAnnouncements() []Announcement {
+	g := r.game
+	announcements := checkAnnouncements(&g.pos)
+	if g.IsOver() {
+		return announcements
+	}
+
+	// A capture that promotes counts once, not once per promotion piece
+	tries := make(map[[2]board.Position]bool)
+	for _, move := range g.LegalMoves() {
+		if g.Board.GetPiece(move.From).Type == board.Pawn && move.From.Col != move.To.Col {
+			tries[[2]board.Position{move.From, move.To}] = true
+		}
+	}
+	if len(tries) > 0 {
+		announcements = append(announcements, Announcement{Kind: PawnTries, Color: g.CurrentPlayer, Count: len(tries)})
+	}
+	return announcements
+}
+
+// checkAnnouncements returns the direction of each check to the player to
+// move
+func checkAnnouncements(p *bitboard.Position) []Announcement {
+	us := p.SideToMove()
+	king := p.King(us)
+	if king == bitboard.NoSquare {
+		return nil
+	}
+
+	var announcements []Announcement
+	attackers := p.Attackers(king, opponentOf(us), p.Occupied())
+	for attackers != 0 {
+		from := attackers.PopFirst()
+		var kind AnnouncementKind
+		switch {
+		case p.Piece(from).Type == board.Knight:
+			kind = KnightCheck
+		case from.Row() == king.Row():
+			kind = CheckOnRank
+		case from.Col() == king.Col():
+			kind = CheckOnFile
+		default:
+			slope := -1
+			if from.Row()-king.Row() == from.Col()-king.Col() {
+				slope = 1
+			}
+			kind = CheckOnShortDiagonal
+			if diagonalLength(king, slope) > diagonalLength(king, -slope) {
+				kind = CheckOnLongDiagonal
+			}
+		}
+		announcements = append(announcements, Announcement{Kind: kind, Color: us})
+	}
+	return announcements
+}
+
+// diagonalLength returns the number of squares on the diagonal through s
+// that runs down and to the right if slope is 1, or up and to the right if
+// slope is -1
+func diagonalLength(s bitboard.Square, slope int) int {
+	if slope == 1 {
+		return 8 - abs(s.Row()-s.Col())
+	}
+	return 8 - abs(s.Row()+s.Col()-7)
+}
+
+// capturedBy returns the piece a legal move captures and the square it
+// stands on, which differs from the destination for en passant
+func (g *Game) capturedBy(m Move) (board.Piece, board.Position) {
+	if g.isEnPassant(m) {
+		square := board.Position{Row: m.From.Row, Col: m.To.Col}
+		return g.Board.GetPiece(square), square
+	}
+	if g.castlingRight(m) != bitboard.NoCastling {
+		// Chess960 castling is written as the king taking its own rook
+		return board.Piece{}, m.To
+	}
+	return g.Board.GetPiece(m.To), m.To
+}
@@ -0,0 +1,45 @@
+package game
+
+import "github.com/user/chess/pkg/bitboard"
+
+// Perft counts the leaf nodes of the legal move tree to the given depth.
+// Comparing the counts with published values verifies move generation.
+// The moves are those the variant allows, and the tree goes on past
+// positions where the variant ends the game. The game itself is not
+// changed.
+func (g *Game) Perft(depth int) int {
+	p := g.Position()
+	return g.perft(&p, depth)
+}
+
+// perft counts the leaf nodes below p, making and taking back moves on it
+func (g *Game) perft(p *bitboard.Position, depth int) int {
+	if depth <= 0 {
+		return 1
+	}
+	moves := g.legalMoves(p)
+	if depth == 1 {
+		return len(moves)
+	}
+
+	nodes := 0
+	for _, m := range moves {
+		undo := p.MakeMove(m)
+		nodes += g.perft(p, depth-1)
+		p.UnmakeMove(m, undo)
+	}
+	return nodes
+}
+
+// Divide returns the perft count below each legal move, keyed by the move
+// in coordinate notation such as "e2e4" or "e7e8q"
+func (g *Game) Divide(depth int) map[string]int {
+	counts := make(map[string]int)
+	p := g.Position()
+	for _, move := range g.legalMoves(&p) {
+		undo := p.MakeMove(move)
+		counts[move.String()] = g.perft(&p, depth-1)
+		p.UnmakeMove(move, undo)
+	}
+	return counts
+}
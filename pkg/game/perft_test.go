@@ -0,0 +1,129 @@
+package game
+
+import "testing"
+
+// perftPositions are the standard perft positions with their published
+// node counts. See https://www.chessprogramming.org/Perft_Results.
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes []int // Node counts for depth 1, 2, ...
+}{
+	{"initial", StartFEN, []int{20, 400, 8902, 197281, 4865609}},
+	{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []int{48, 2039, 97862, 4085603}},
+	{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []int{14, 191, 2812, 43238, 674624}},
+	{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []int{6, 264, 9467, 422333}},
+	{"position 4 mirrored", "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1", []int{6, 264, 9467, 422333}},
+	{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []int{44, 1486, 62379, 2103487}},
+	{"position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", []int{46, 2079, 89890, 3894594}},
+
+	// Chess960 positions, with the castling rights in Shredder-FEN
+	{"chess960 1", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", []int{21, 528, 12189, 326672}},
+	{"chess960 2", "2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", []int{21, 807, 18002, 667366}},
+	{"chess960 3", "b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", []int{20, 479, 10471, 273318}},
+	{"chess960 4", "qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9", []int{22, 593, 13440, 382958}},
+	{"chess960 5", "1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9", []int{28, 1120, 31058, 1171749}},
+	{"chess960 6", "qnbnr1kr/ppp1b1pp/4p3/3p1p2/8/2NPP3/PPP1BPPP/QNB1R1KR w HEhe - 1 9", []int{29, 899, 26578, 824055}},
+	{"chess960 7", "q1bnrkr1/ppppp2p/2n2p2/4b1p1/2NP4/8/PPP1PPPP/QNB1RRKB w ge - 1 9", []int{30, 860, 24566, 732757}},
+	{"chess960 8", "qbn1brkr/ppp1p1p1/2n4p/3p1p2/P7/6PP/QPPPPP2/1BNNBRKR w HFhf - 0 9", []int{25, 635, 17054, 465806}},
+	{"chess960 9", "qn1rbbkr/ppp2p1p/1n1pp1p1/8/3P4/P6P/1PP1PPPK/QNNRBB1R w hd - 2 9", []int{28, 811, 23175, 679699}},
+}
+
+// variantPerftPositions are perft positions for the variants that change
+// how pieces move or capture
+var variantPerftPositions = []struct {
+	name    string
+	variant Variant
+	fen     string
+	nodes   []int // Node counts for depth 1, 2, ...
+}{
+	{"atomic initial", Atomic, StartFEN, []int{20, 400, 8902, 197326, 4864979}},
+	{"atomic programfox 1", Atomic, "rn2kb1r/1pp1p2p/p2q1pp1/3P4/2P3b1/4PN2/PP3PPP/R2QKB1R b KQkq - 0 1", []int{40, 1238, 45237, 1434825}},
+	{"atomic programfox 2", Atomic, "rn1qkb1r/p5pp/2p5/3p4/N3P3/5P2/PPP4P/R1BQK3 w Qkq - 0 1", []int{28, 833, 23353, 714499}},
+
+	// Atomic960 castling, where attacks may pass through the king's square
+	// when it stands next to the enemy king
+	{"atomic960 castling 1", Atomic, "8/8/8/8/8/8/2k5/rR4KR w HB - 0 1", []int{18, 180, 4364, 61401}},
+	{"atomic960 castling 2", Atomic, "r3k1rR/5K2/8/8/8/8/8/8 b ga - 0 1", []int{25, 282, 6753, 98729}},
+	{"atomic960 castling 3", Atomic, "Rr2k1rR/3K4/3p4/8/8/8/7P/8 w gb - 0 1", []int{21, 465, 10631, 241478}},
+
+	{"antichess initial", Antichess, StartFEN, []int{20, 400, 8067, 153299, 2732672}},
+	{"antichess a-pawn vs b-pawn", Antichess, "8/1p6/8/8/8/8/P7/8 w - - 0 1", []int{2, 4, 4, 3, 1}},
+	{"antichess a-pawn vs c-pawn", Antichess, "8/2p5/8/8/8/8/P7/8 w - - 0 1", []int{2, 4, 4, 4, 4}},
+	{"antichess promotion to king", Antichess, "8/2P3k1/8/8/1p6/8/6K1/8 w - - 0 1", []int{13, 117, 1455, 10297, 133400}},
+	{"antichess forced en passant", Antichess, "rnbqkbnr/pppp1ppp/8/8/3Pp3/8/PPP1PPPP/RNBQKBNR b - d3 0 1", []int{1, 3, 87, 1483, 25334}},
+
+	{"horde initial", Horde, "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1", []int{8, 128, 1274, 23310, 265223}},
+	{"horde open flank", Horde, "4k3/pp4q1/3P2p1/8/P3PP2/PPP2r2/PPP5/PPPP4 b - - 0 1", []int{30, 241, 6633, 56539}},
+	{"horde en passant", Horde, "k7/5p2/4p2P/3p2P1/2p2P2/1p2P2P/p2P2P1/2P2P2 w - - 0 1", []int{13, 172, 2205, 33781}},
+
+	{"racing kings initial", RacingKings, "8/8/8/8/8/8/krbnNBRK/qrbnNBRQ w - - 0 1", []int{21, 421, 11264, 296242, 9472927}},
+}
+
+func TestVariantPerft(t *testing.T) {
+	for _, tt := range variantPerftPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(tt.variant))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.nodes {
+				depth := i + 1
+				if testing.Short() && want > 10000 {
+					break
+				}
+				if got := g.Perft(depth); got != want {
+					t.Errorf("Perft(%d) = %d, want %d", depth, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPerft(t *testing.T) {
+	for _, tt := range perftPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.nodes {
+				depth := i + 1
+				if testing.Short() && want > 10000 {
+					break
+				}
+				if got := g.Perft(depth); got != want {
+					t.Errorf("Perft(%d) = %d, want %d", depth, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDivide(t *testing.T) {
+	g, err := NewGameFromFEN(perftPositions[1].fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := g.Divide(2)
+	if len(counts) != 48 {
+		t.Errorf("Divide(2) returned %d moves, want 48", len(counts))
+	}
+	total := 0
+	for _, nodes := range counts {
+		total += nodes
+	}
+	if total != 2039 {
+		t.Errorf("Divide(2) sums to %d, want 2039", total)
+	}
+	// Castling and the en passant capture are listed like other moves
+	for _, move := range []string{"e1g1", "e1c1", "d5e6", "a2a3"} {
+		if _, ok := counts[move]; !ok {
+			t.Errorf("Divide(2) is missing %s", move)
+		}
+	}
+	if got := g.Divide(1)["e1g1"]; got != 1 {
+		t.Errorf("Divide(1)[e1g1] = %d, want 1", got)
+	}
+}
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/pgn"
+)
+
+// sevenTagRoster lists the tags every PGN game starts with, in order
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// setupTags lists the tags of a game that starts from a given position, in
+// the order they follow the Seven Tag Roster
+var setupTags = []string{"SetUp", "FEN"}
+
+// pgnLineLength is the maximum length of a movetext line
+const pgnLineLength = 79
+
+// WritePGN writes the game in Portable Game Notation. The Seven Tag Roster
+// is always written; entries in tags override the defaults or add extra
+// tags. The Result tag always reflects the state of the game, and finished
+// games get a Termination tag.
+func (g *Game) WritePGN(w io.Writer, tags map[string]string) error {
+	values := map[string]string{
+		"Event": "?",
+		"Site":  "?",
+		"Date":  g.startTime.Format("2006.01.02"),
+		"Round": "-",
+		"White": playerTag(g.WhitePlayer),
+		"Black": playerTag(g.BlackPlayer),
+	}
+	if g.IsOver() {
+		values["Termination"] = g.terminationTag()
+	}
+	for name, value := range tags {
+		values[name] = value
+	}
+	values["Result"] = g.resultToken()
+	if g.startFEN != "" {
+		values["SetUp"] = "1"
+		values["FEN"] = g.startFEN
+	}
+	if g.variant != Standard {
+		values["Variant"] = g.variant.Name()
+	}
+
+	bw := bufio.NewWriter(w)
+
+	// The Seven Tag Roster comes first, then SetUp and FEN, then the
+	// remaining tags in alphabetical order
+	names := append([]string(nil), sevenTagRoster...)
+	for _, name := range setupTags {
+		if _, ok := values[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var extra []string
+	for name := range values {
+		if !isSevenTagRoster(name) && !isSetupTag(name) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range append(names, extra...) {
+		fmt.Fprintf(bw, "[%s \"%s\"]\n", name, escapeTagValue(values[name]))
+	}
+	bw.WriteString("\n")
+
+	line := 0
+	for _, token := range g.movetext() {
+		if line > 0 && line+1+len(token) > pgnLineLength {
+			bw.WriteString("\n")
+			line = 0
+		}
+		if line > 0 {
+			bw.WriteString(" ")
+			line++
+		}
+		bw.WriteString(token)
+		line += len(token)
+	}
+	bw.WriteString("\n\n")
+
+	return bw.Flush()
+}
+
+// movetext returns the move numbers, moves and result of the game as
+// separate PGN tokens
+func (g *Game) movetext() []string {
+	// Work back from the current position to the ply the game started on
+	ply := (g.pos.FullMoveNumber()-1)*2 - len(g.moveHistory)
+	if g.CurrentPlayer == board.Black {
+		ply++
+	}
+
+	var tokens []string
+	for i, move := range g.moveHistory {
+		number := ply/2 + 1
+		if ply%2 == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d.", number))
+		} else if i == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d...", number))
+		}
+		tokens = append(tokens, move.Notation)
+		ply++
+	}
+
+	return append(tokens, g.resultToken())
+}
+
+// resultToken returns the PGN result of the game
+func (g *Game) resultToken() string {
+	return g.result.Outcome.String()
+}
+
+// terminationTag returns the value of the PGN Termination tag for a
+// finished game
+func (g *Game) terminationTag() string {
+	switch g.result.Reason {
+	case ReasonTimeout, ReasonTimeoutVsInsufficientMaterial:
+		return "time forfeit"
+	case ReasonAbandonment:
+		return "abandoned"
+	}
+	return "normal"
+}
+
+// pgnResult works out why a game read from PGN ended when its final
+// position does not show it, using the result and the Termination tag.
+// Decisive games are taken to have been resigned and draws to have been
+// claimed or agreed.
+func (g *Game) pgnResult(outcome Outcome, termination string) Result {
+	switch strings.ToLower(termination) {
+	case "time forfeit":
+		if outcome == Drawn {
+			return draw(ReasonTimeoutVsInsufficientMaterial)
+		}
+		return Result{Outcome: outcome, Reason: ReasonTimeout}
+	case "abandoned":
+		return Result{Outcome: outcome, Reason: ReasonAbandonment}
+	}
+
+	if outcome != Drawn {
+		return Result{Outcome: outcome, Reason: ReasonResignation}
+	}
+	if g.RepetitionCount() >= 3 {
+		return draw(ReasonThreefoldRepetition)
+	}
+	if g.pos.HalfMoveClock() >= 100 {
+		return draw(ReasonFiftyMoves)
+	}
+	return draw(ReasonAgreement)
+}
+
+// playerTag returns the value of a player tag, using "?" for unknown players
+func playerTag(name string) string {
+	if name == "" {
+		return "?"
+	}
+	return name
+}
+
+func isSevenTagRoster(name string) bool {
+	for _, tag := range sevenTagRoster {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isSetupTag(name string) bool {
+	for _, tag := range setupTags {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeTagValue escapes backslashes and quotes in a tag value
+func escapeTagValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+// NewGameFromPGN creates a game by replaying the main line of a game read
+// from a PGN database. Games that start from a custom position must have a
+// FEN tag, and games of other variants a Variant tag; a Chess960 game
+// without a FEN starts from the standard position. A game that ended
+// before its final position was decided keeps the result of the PGN game.
+func NewGameFromPGN(pg *pgn.Game) (*Game, error) {
+	variantName, _ := pg.Tag("Variant")
+	variant, err := ParseVariant(variantName)
+	if err != nil {
+		return nil, err
+	}
+
+	fen, _ := pg.Tag("FEN")
+	g, err := newGameFrom(variant, fen)
+	if err != nil {
+		return nil, err
+	}
+
+	if white, ok := pg.Tag("White"); ok && white != "?" {
+		g.WhitePlayer = white
+	}
+	if black, ok := pg.Tag("Black"); ok && black != "?" {
+		g.BlackPlayer = black
+	}
+	if date, ok := pg.Tag("Date"); ok {
+		if started, err := time.Parse("2006.01.02", date); err == nil {
+			g.startTime = started
+		}
+	}
+
+	// Replay without running the clock
+	timeControl := g.TimeControl
+	g.TimeControl = nil
+	for i, pm := range pg.Moves {
+		move, err := g.parseSAN(pm.SAN)
+		if err == nil {
+			err = g.PlayMove(move)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error replaying move %d (%s): %v", i+1, pm.SAN, err)
+		}
+	}
+	g.TimeControl = timeControl
+
+	if outcome, ok := parseOutcome(pg.Result); ok && outcome != Ongoing && !g.IsOver() {
+		termination, _ := pg.Tag("Termination")
+		g.finish(g.pgnResult(outcome, termination))
+	}
+
+	return g, nil
+}
@@ -0,0 +1,148 @@
+package game
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/chess/pkg/pgn"
+)
+
+func TestWritePGN(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	g.SetPlayerNames("Alice", "Bob \"The Rook\"")
+	playMoves(t, g, "e2 e4", "e7 e5", "f1 c4", "b8 c6", "d1 h5", "g8 f6", "h5 f7")
+
+	var sb strings.Builder
+	if err := g.WritePGN(&sb, map[string]string{"Event": "Club night", "ECO": "C23"}); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+
+	wantPrefix := "[Event \"Club night\"]\n[Site \"?\"]\n[Date \"" + g.startTime.Format("2006.01.02") + "\"]\n" +
+		"[Round \"-\"]\n[White \"Alice\"]\n[Black \"Bob \\\"The Rook\\\"\"]\n[Result \"1-0\"]\n[ECO \"C23\"]\n[Termination \"normal\"]\n\n"
+	if !strings.HasPrefix(got, wantPrefix) {
+		t.Errorf("WritePGN() tags =\n%s\nwant prefix\n%s", got, wantPrefix)
+	}
+	if want := "1. e4 e5 2. Bc4 Nc6 3. Qh5 Nf6 4. Qxf7# 1-0\n"; !strings.Contains(got, want) {
+		t.Errorf("WritePGN() movetext =\n%s\nwant %q", got, want)
+	}
+}
+
+func TestWritePGNFromPosition(t *testing.T) {
+	fen := "4k3/8/8/8/8/7p/8/R3K3 b Q - 0 40"
+	g, err := NewGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "e8 d7", "e1 c1")
+
+	var sb strings.Builder
+	if err := g.WritePGN(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+
+	for _, want := range []string{
+		"[White \"?\"]\n",
+		"[Result \"*\"]\n[SetUp \"1\"]\n[FEN \"" + fen + "\"]\n",
+		"40... Kd7 41. O-O-O+ *\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WritePGN() =\n%s\nwant it to contain %q", got, want)
+		}
+	}
+}
+
+func TestWritePGNLineLength(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	rng := rand.New(rand.NewSource(3))
+	for ply := 0; ply < 80 && (g.State == InProgress || g.State == Check); ply++ {
+		moves := g.LegalMoves()
+		if err := g.PlayMove(moves[rng.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var sb strings.Builder
+	if err := g.WritePGN(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if len(line) > pgnLineLength {
+			t.Errorf("line longer than %d characters: %q", pgnLineLength, line)
+		}
+	}
+}
+
+func TestNewGameFromPGN(t *testing.T) {
+	const input = `[White "Alice"]
+[Black "Bob"]
+[FEN "r3k2r/p7/8/8/8/8/4p3/R3KB1R b KQkq - 0 1"]
+[SetUp "1"]
+
+1... O-O-O 2. Rxa7 {Grabbing} (2. Bd3 exd1=Q+) 2... exf1=N 3. Rxf1 Kb8 *
+`
+	pg, err := pgn.NewParser(strings.NewReader(input)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGameFromPGN(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "1k1r3r/R7/8/8/8/8/8/4KR2 w - - 1 4"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+	if g.WhitePlayer != "Alice" || g.BlackPlayer != "Bob" {
+		t.Errorf("players = %q, %q", g.WhitePlayer, g.BlackPlayer)
+	}
+}
+
+func TestLoadPGN(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "games.pgn")
+	content := "1. e4 e5 *\n\n1. d4 d5 2. c4 *\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGame()
+	if err := g.LoadPGN(filename, 2); err != nil {
+		t.Fatal(err)
+	}
+	if want := "rnbqkbnr/ppp1pppp/8/3p4/2PP4/8/PP2PPPP/RNBQKBNR b KQkq c3 0 2"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+
+	if err := g.LoadPGN(filename, 3); err == nil {
+		t.Error("LoadPGN() of a missing game expected an error")
+	}
+}
+
+func TestPGNRoundTrip(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	playMoves(t, g, "e2 e4", "d7 d5", "e4 d5", "g8 f6", "f1 b5", "c7 c6", "d5 c6", "d8 b6", "c6 b7", "b6 b5", "b7 a8")
+
+	var sb strings.Builder
+	if err := g.WritePGN(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	pg, err := pgn.NewParser(strings.NewReader(sb.String())).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewGameFromPGN(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FEN() != g.FEN() {
+		t.Errorf("round trip FEN = %q, want %q", loaded.FEN(), g.FEN())
+	}
+}
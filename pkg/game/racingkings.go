@@ -0,0 +1,85 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// racingKings is a race to bring the king to the eighth rank. Both sides
+// start on the first two ranks, without pawns, and no move may give check.
+// If White gets there first, Black has one move to follow and draw.
+type racingKings struct{ standard }
+
+func (racingKings) Name() string {
+	return "Racing Kings"
+}
+
+func (racingKings) Setup(n int) bitboard.Position {
+	return bitboard.NewPosition(board.NewRacingKingsBoard(), board.White)
+}
+
+// LegalMoves takes out the moves that give check
+func (racingKings) LegalMoves(p *bitboard.Position) []bitboard.Move {
+	moves := p.LegalMoves()
+	them := opponentOf(p.SideToMove())
+	quiet := moves[:0]
+	for _, m := range moves {
+		undo := p.MakeMove(m)
+		check := p.InCheck(them)
+		p.UnmakeMove(m, undo)
+		if !check {
+			quiet = append(quiet, m)
+		}
+	}
+	return quiet
+}
+
+// ParseFEN ignores castling rights, as there is no castling, and rejects
+// positions where a king is in check
+func (racingKings) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	p, err := parse(fields)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	if p.InCheck(board.White) || p.InCheck(board.Black) {
+		return bitboard.Position{}, errors.New("a king is in check")
+	}
+	p.SetCastling(bitboard.NoCastling)
+	return p, nil
+}
+
+func (racingKings) Result(g *Game, result Result) Result {
+	white, black := onGoal(&g.pos, board.White), onGoal(&g.pos, board.Black)
+	switch {
+	case white && black:
+		return draw(ReasonGoal)
+	case black:
+		return win(board.Black, ReasonGoal)
+	case white && (g.CurrentPlayer == board.White || !canReachGoal(g)):
+		return win(board.White, ReasonGoal)
+	}
+
+	// A lone king can still win the race
+	return ignoreMaterialDraws(g, result)
+}
+
+// onGoal reports whether the king of the given color is on the eighth rank
+func onGoal(p *bitboard.Position, color board.Color) bool {
+	king := p.King(color)
+	return king != bitboard.NoSquare && king.Row() == 0
+}
+
+// canReachGoal reports whether the player to move has a legal king move to
+// the eighth rank
+func canReachGoal(g *Game) bool {
+	p := g.Position()
+	king := p.King(g.CurrentPlayer)
+	for _, m := range g.legalMoves(&p) {
+		if m.From == king && m.Drop == board.Empty && m.To.Row() == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/user/chess/pkg/board"
+)
+
+// Outcome is who won a game, if anyone
+type Outcome int
+
+const (
+	Ongoing Outcome = iota
+	WhiteWins
+	BlackWins
+	Drawn
+)
+
+// String returns the outcome as a PGN game termination marker
+func (o Outcome) String() string {
+	switch o {
+	case WhiteWins:
+		return "1-0"
+	case BlackWins:
+		return "0-1"
+	case Drawn:
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+// parseOutcome parses a PGN game termination marker
+func parseOutcome(token string) (Outcome, bool) {
+	for _, o := range []Outcome{Ongoing, WhiteWins, BlackWins, Drawn} {
+		if o.String() == token {
+			return o, true
+		}
+	}
+	return Ongoing, false
+}
+
+// Reason is why a game ended
+type Reason int
+
+const (
+	NoReason Reason = iota
+	ReasonCheckmate
+	ReasonResignation
+	ReasonTimeout
+	ReasonTimeoutVsInsufficientMaterial // Time ran out, but the opponent could not have mated
+	ReasonAgreement
+	ReasonStalemate
+	ReasonThreefoldRepetition // Claimed by a player
+	ReasonFivefoldRepetition
+	ReasonFiftyMoves // Claimed by a player
+	ReasonSeventyFiveMoves
+	ReasonInsufficientMaterial
+	ReasonDeadPosition
+	ReasonAbandonment
+	ReasonKingOfTheHill  // The king reached the center in King of the Hill
+	ReasonThreeChecks    // Check was given three times in Three-check
+	ReasonExplosion      // The king was blown up in Atomic
+	ReasonNoPieces       // The winner lost all pieces in Antichess
+	ReasonHordeDestroyed // Every White pawn and piece was captured in Horde
+	ReasonGoal           // A king reached the eighth rank in Racing Kings
+	ReasonKingCaptured   // The king was captured in Fog of War
+)
+
+// reasonNames are the names of the reasons as used in descriptions and
+// save files
+var reasonNames = map[Reason]string{
+	ReasonCheckmate:                     "checkmate",
+	ReasonResignation:                   "resignation",
+	ReasonTimeout:                       "timeout",
+	ReasonTimeoutVsInsufficientMaterial: "timeout vs insufficient material",
+	ReasonAgreement:                     "agreement",
+	ReasonStalemate:                     "stalemate",
+	ReasonThreefoldRepetition:           "threefold repetition",
+	ReasonFivefoldRepetition:            "fivefold repetition",
+	ReasonFiftyMoves:                    "the 50-move rule",
+	ReasonSeventyFiveMoves:              "the 75-move rule",
+	ReasonInsufficientMaterial:          "insufficient material",
+	ReasonDeadPosition:                  "dead position",
+	ReasonAbandonment:                   "abandonment",
+	ReasonKingOfTheHill:                 "king in the center",
+	ReasonThreeChecks:                   "three checks",
+	ReasonExplosion:                     "king explosion",
+	ReasonNoPieces:                      "losing all pieces",
+	ReasonHordeDestroyed:                "destroying the horde",
+	ReasonGoal:                          "reaching the eighth rank",
+	ReasonKingCaptured:                  "capturing the king",
+}
+
+// String returns the name of the reason
+func (r Reason) String() string {
+	return reasonNames[r]
+}
+
+// parseReason looks up a reason by its name
+func parseReason(name string) (Reason, bool) {
+	for reason, reasonName := range reasonNames {
+		if reasonName == name {
+			return reason, true
+		}
+	}
+	return NoReason, false
+}
+
+// Result is the outcome of a game together with the reason it ended
+type Result struct {
+	Outcome Outcome
+	Reason  Reason
+}
+
+// IsOver reports whether the result ends the game
+func (r Result) IsOver() bool {
+	return r.Outcome != Ongoing
+}
+
+// Description returns the result in words, such as "White wins by
+// checkmate" or "Draw by stalemate"
+func (r Result) Description() string {
+	switch r.Outcome {
+	case Ongoing:
+		return "Game in progress"
+	case Drawn:
+		if r.Reason == NoReason {
+			return "Draw"
+		}
+		return "Draw by " + r.Reason.String()
+	}
+
+	winner := "White"
+	if r.Outcome == BlackWins {
+		winner = "Black"
+	}
+	switch r.Reason {
+	case NoReason:
+		return winner + " wins"
+	case ReasonTimeout:
+		return winner + " wins on time"
+	}
+	return winner + " wins by " + r.Reason.String()
+}
+
+// win returns the result of a game won by the given color
+func win(color board.Color, reason Reason) Result {
+	if color == board.White {
+		return Result{Outcome: WhiteWins, Reason: reason}
+	}
+	return Result{Outcome: BlackWins, Reason: reason}
+}
+
+// draw returns the result of a drawn game
+func draw(reason Reason) Result {
+	return Result{Outcome: Drawn, Reason: reason}
+}
+
+// Result returns the result of the game, with an Ongoing outcome while the
+// game is in progress
+func (g *Game) Result() Result {
+	return g.result
+}
+
+// IsOver reports whether the game has ended
+func (g *Game) IsOver() bool {
+	return g.result.IsOver()
+}
+
+// finish ends the game with the given result
+func (g *Game) finish(result Result) {
+	g.result = result
+	switch result.Reason {
+	case ReasonCheckmate:
+		g.State = Checkmate
+	case ReasonStalemate:
+		g.State = Stalemate
+	case ReasonTimeout, ReasonTimeoutVsInsufficientMaterial:
+		g.State = TimeOut
+	default:
+		if result.Outcome == Drawn {
+			g.State = Draw
+		} else {
+			g.State = Finished
+		}
+	}
+}
+
+// Abandon ends the game because the player of the given color left it.
+// The opponent wins.
+func (g *Game) Abandon(color board.Color) error {
+	if g.IsOver() {
+		return errGameOver
+	}
+	g.finish(win(opponentOf(color), ReasonAbandonment))
+	return nil
+}
+
+// Resign ends the game with a win for the opponent of the given color
+func (g *Game) Resign(color board.Color) error {
+	if g.IsOver() {
+		return errGameOver
+	}
+	g.finish(win(opponentOf(color), ReasonResignation))
+	return nil
+}
+
+// OfferDraw records a draw offer by the player of the given color. The
+// offer stands until the opponent accepts or declines it, or makes a move.
+func (g *Game) OfferDraw(color board.Color) error {
+	if g.IsOver() {
+		return errGameOver
+	}
+	if g.drawOffer == opponentOf(color) {
+		return errors.New("your opponent has already offered a draw")
+	}
+	g.drawOffer = color
+	return nil
+}
+
+// DrawOffer returns the color of the player whose draw offer is pending,
+// or NoColor if there is none
+func (g *Game) DrawOffer() board.Color {
+	return g.drawOffer
+}
+
+// AcceptDraw accepts the opponent's draw offer, ending the game
+func (g *Game) AcceptDraw(color board.Color) error {
+	if g.IsOver() {
+		return errGameOver
+	}
+	if g.drawOffer != opponentOf(color) {
+		return errors.New("there is no draw offer to accept")
+	}
+	g.drawOffer = board.NoColor
+	g.finish(draw(ReasonAgreement))
+	return nil
+}
+
+// DeclineDraw declines the opponent's draw offer
+func (g *Game) DeclineDraw(color board.Color) error {
+	if g.IsOver() {
+		return errGameOver
+	}
+	if g.drawOffer != opponentOf(color) {
+		return errors.New("there is no draw offer to decline")
+	}
+	g.drawOffer = board.NoColor
+	return nil
+}
+
+// ClaimDraw claims a draw for the player to move by threefold repetition
+// or the 50-move rule. The claim is refused if the position does not
+// support it.
+func (g *Game) ClaimDraw(reason Reason) error {
+	if g.IsOver() {
+		return errGameOver
+	}
+	switch reason {
+	case ReasonThreefoldRepetition:
+		if g.RepetitionCount() < 3 {
+			return errors.New("the position has not occurred three times")
+		}
+	case ReasonFiftyMoves:
+		if g.pos.HalfMoveClock() < 100 {
+			return fmt.Errorf("only %d moves without a capture or pawn move", g.pos.HalfMoveClock()/2)
+		}
+	default:
+		return fmt.Errorf("a draw cannot be claimed by %s", reason)
+	}
+	g.finish(draw(reason))
+	return nil
+}
+
+// timeOut ends the game because the player to move ran out of time. The
+// opponent wins unless their material could never mate, as with a lone
+// king or a king and one minor piece, or the variant decides otherwise.
+func (g *Game) timeOut() {
+	// The flag only loses if the opponent could still mate by some series
+	// of legal moves. A lone king never can, but a lone knight or bishop
+	// can once the flagged side's own pieces hem its king in, so the draw
+	// is decided from the material of both sides.
+	result := win(g.opponent(), ReasonTimeout)
+	if g.hasOnlyKing(g.opponent()) || g.cannotMate(board.White, board.Black) {
+		result = draw(ReasonTimeoutVsInsufficientMaterial)
+	}
+	g.finish(g.variant.Result(g, result))
+}
+
+// hasOnlyKing reports whether the king is the only piece of the given color
+func (g *Game) hasOnlyKing(color board.Color) bool {
+	return g.pos.Color(color) == g.pos.Pieces(color, board.King)
+}
@@ -0,0 +1,240 @@
+package game
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/pgn"
+)
+
+func TestResultDescription(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   string
+	}{
+		{Result{}, "Game in progress"},
+		{win(board.White, ReasonCheckmate), "White wins by checkmate"},
+		{win(board.Black, ReasonResignation), "Black wins by resignation"},
+		{win(board.White, ReasonTimeout), "White wins on time"},
+		{win(board.Black, ReasonAbandonment), "Black wins by abandonment"},
+		{draw(ReasonTimeoutVsInsufficientMaterial), "Draw by timeout vs insufficient material"},
+		{draw(ReasonAgreement), "Draw by agreement"},
+		{draw(ReasonFiftyMoves), "Draw by the 50-move rule"},
+		{draw(NoReason), "Draw"},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Description(); got != tt.want {
+			t.Errorf("Description() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestTimeoutResult(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want Result
+	}{
+		{"opponent can mate", "4k3/8/8/8/8/8/4p3/1K6 w - - 0 1", win(board.Black, ReasonTimeout)},
+		{"opponent has a lone king", "4k3/8/8/8/8/8/4P3/1K6 w - - 0 1", draw(ReasonTimeoutVsInsufficientMaterial)},
+		{"opponent has a lone king against a queen", "4k3/8/8/8/8/8/8/1KQ5 w - - 0 1", draw(ReasonTimeoutVsInsufficientMaterial)},
+		{"opponent has a knight against a pawn", "4k3/8/8/8/8/8/4P3/1K4n1 w - - 0 1", win(board.Black, ReasonTimeout)},
+		{"opponent has a bishop against a knight", "4k3/8/8/8/8/8/8/1K2N1b1 w - - 0 1", win(board.Black, ReasonTimeout)},
+		{"opponent has bishops on one color", "4k3/8/8/8/8/8/4P3/1K3b1b w - - 0 1", win(board.Black, ReasonTimeout)},
+		{"opponent has two knights", "4k3/8/8/8/8/8/4P3/1K3n1n w - - 0 1", win(board.Black, ReasonTimeout)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl.WhiteTimeLeft = 0
+
+			if err := g.MakeMove(mustPos(t, "b1"), mustPos(t, "b2")); err == nil {
+				t.Fatal("MakeMove after the flag fell succeeded")
+			}
+			if g.Result() != tt.want || g.State != TimeOut {
+				t.Errorf("Result() = %v (%v), want %v", g.Result(), g.State, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveLoadKeepsResult(t *testing.T) {
+	for _, ext := range []string{".json", ".pgn"} {
+		g := NewGame()
+		g.TimeControl = nil
+		playMoves(t, g, "e2 e4", "e7 e5")
+		if err := g.Abandon(board.Black); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.MakeMove(mustPos(t, "g1"), mustPos(t, "f3")); err == nil {
+			t.Error("MakeMove after the game was abandoned succeeded")
+		}
+
+		filename := filepath.Join(t.TempDir(), "game"+ext)
+		if err := g.SaveGame(filename); err != nil {
+			t.Fatal(err)
+		}
+		loaded := NewGame()
+		if err := loaded.LoadGame(filename); err != nil {
+			t.Fatal(err)
+		}
+		if want := win(board.White, ReasonAbandonment); loaded.Result() != want || loaded.State != Finished {
+			t.Errorf("%s: loaded Result() = %v (%v), want %v", ext, loaded.Result(), loaded.State, want)
+		}
+	}
+}
+
+func TestPGNResultInference(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Result
+	}{
+		{"resignation", "1. e4 e5 0-1", win(board.Black, ReasonResignation)},
+		{"agreement", "1. e4 e5 1/2-1/2", draw(ReasonAgreement)},
+		{"repetition claim", "1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 4. Ng1 Ng8 1/2-1/2", draw(ReasonThreefoldRepetition)},
+		{"time forfeit", "[Termination \"time forfeit\"]\n\n1. e4 1-0", win(board.White, ReasonTimeout)},
+		{"final position decides", "1. f3 e5 2. g4 Qh4# 1-0", win(board.Black, ReasonCheckmate)},
+		{"unfinished", "1. e4 *", Result{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, err := pgn.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := NewGameFromPGN(games[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Result() != tt.want {
+				t.Errorf("Result() = %v, want %v", g.Result(), tt.want)
+			}
+		})
+	}
+}
+
+func TestResign(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	if err := g.Resign(board.White); err != nil {
+		t.Fatal(err)
+	}
+	if want := win(board.Black, ReasonResignation); g.Result() != want || g.State != Finished {
+		t.Errorf("Result() = %v (%v), want %v", g.Result(), g.State, want)
+	}
+	if err := g.Resign(board.Black); err == nil {
+		t.Error("Resign after the game ended succeeded")
+	}
+}
+
+func TestDrawOffers(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+
+	if err := g.AcceptDraw(board.Black); err == nil {
+		t.Error("AcceptDraw without an offer succeeded")
+	}
+	if err := g.OfferDraw(board.White); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AcceptDraw(board.White); err == nil {
+		t.Error("AcceptDraw of one's own offer succeeded")
+	}
+
+	// The offer stands while the player who made it moves, and expires once
+	// the opponent moves instead of accepting
+	playMoves(t, g, "e2 e4")
+	if g.DrawOffer() != board.White {
+		t.Fatalf("DrawOffer() = %v after the offering player moved", g.DrawOffer())
+	}
+	playMoves(t, g, "e7 e5")
+	if g.DrawOffer() != board.NoColor {
+		t.Fatalf("DrawOffer() = %v after the opponent moved", g.DrawOffer())
+	}
+	if err := g.AcceptDraw(board.Black); err == nil {
+		t.Error("AcceptDraw of an expired offer succeeded")
+	}
+
+	if err := g.OfferDraw(board.White); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclineDraw(board.White); err == nil {
+		t.Error("DeclineDraw of one's own offer succeeded")
+	}
+	if g.DrawOffer() != board.White {
+		t.Fatalf("DrawOffer() = %v after declining one's own offer", g.DrawOffer())
+	}
+	if err := g.DeclineDraw(board.Black); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AcceptDraw(board.Black); err == nil {
+		t.Error("AcceptDraw of a declined offer succeeded")
+	}
+
+	if err := g.OfferDraw(board.Black); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AcceptDraw(board.White); err != nil {
+		t.Fatal(err)
+	}
+	if want := draw(ReasonAgreement); g.Result() != want || g.State != Draw {
+		t.Errorf("Result() = %v (%v), want %v", g.Result(), g.State, want)
+	}
+
+	// An offer still pending when the game ends cannot be answered
+	g = NewGame()
+	if err := g.OfferDraw(board.White); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Resign(board.Black); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.DeclineDraw(board.Black); !errors.Is(err, errGameOver) {
+		t.Errorf("DeclineDraw after the game ended = %v, want %v", err, errGameOver)
+	}
+}
+
+func TestClaimDraw(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	shuffle := []string{"g1 f3", "g8 f6", "f3 g1", "f6 g8"}
+
+	playMoves(t, g, shuffle...)
+	if err := g.ClaimDraw(ReasonThreefoldRepetition); err == nil {
+		t.Error("claim after two occurrences succeeded")
+	}
+	if err := g.ClaimDraw(ReasonFiftyMoves); err == nil {
+		t.Error("50-move claim after four moves succeeded")
+	}
+	if err := g.ClaimDraw(ReasonAgreement); err == nil {
+		t.Error("claim by agreement succeeded")
+	}
+
+	playMoves(t, g, shuffle...)
+	if err := g.ClaimDraw(ReasonThreefoldRepetition); err != nil {
+		t.Fatal(err)
+	}
+	if want := draw(ReasonThreefoldRepetition); g.Result() != want {
+		t.Errorf("Result() = %v, want %v", g.Result(), want)
+	}
+
+	g, err := NewGameFromFEN("4k3/8/8/8/8/8/8/R3K3 w - - 100 80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ClaimDraw(ReasonFiftyMoves); err != nil {
+		t.Fatal(err)
+	}
+	if want := draw(ReasonFiftyMoves); g.Result() != want {
+		t.Errorf("Result() = %v, want %v", g.Result(), want)
+	}
+}
@@ -0,0 +1,322 @@
+package game
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/piece"
+)
+
+// The reference rules below are the coordinate based checks the game used
+// before move validation moved to pkg/piece and move generation to
+// pkg/bitboard. They are kept as an independent implementation to
+// cross-check the registered validators and the moves the game accepts.
+
+// pieceState returns the game state the move validators need
+func (g *Game) pieceState() piece.State {
+	state := piece.State{
+		CastlingRights: make(map[board.Color]CastlingRights, 2),
+	}
+	if ep := g.pos.EnPassant(); ep != bitboard.NoSquare {
+		target := ep.Position()
+		state.EnPassantTarget = &target
+	}
+	rights := g.pos.Castling()
+	for _, color := range []board.Color{board.White, board.Black} {
+		state.CastlingRights[color] = CastlingRights{
+			KingSide:  rights&bitboard.KingSide(color) != 0,
+			QueenSide: rights&bitboard.QueenSide(color) != 0,
+		}
+	}
+	return state
+}
+
+// referenceValidMove checks if a move is valid for the piece on from
+func (g *Game) referenceValidMove(from, to board.Position) bool {
+	p := g.Board.GetPiece(from)
+	if p.Type == board.Empty || from == to {
+		return false
+	}
+	destPiece := g.Board.GetPiece(to)
+	if destPiece.Type != board.Empty && destPiece.Color == p.Color {
+		return false
+	}
+
+	rowDiff := to.Row - from.Row
+	colDiff := to.Col - from.Col
+	absRowDiff := abs(rowDiff)
+	absColDiff := abs(colDiff)
+
+	switch p.Type {
+	case board.Pawn:
+		forwardDir := -1
+		startRow := 6
+		if p.Color == board.Black {
+			forwardDir = 1
+			startRow = 1
+		}
+		if colDiff == 0 && rowDiff == forwardDir {
+			return g.Board.IsEmpty(to)
+		}
+		if colDiff == 0 && from.Row == startRow && rowDiff == 2*forwardDir {
+			midPos := board.Position{Row: from.Row + forwardDir, Col: from.Col}
+			return g.Board.IsEmpty(midPos) && g.Board.IsEmpty(to)
+		}
+		if absColDiff == 1 && rowDiff == forwardDir {
+			if !g.Board.IsEmpty(to) {
+				return true
+			}
+			ep := g.pos.EnPassant()
+			return ep != bitboard.NoSquare && to == ep.Position()
+		}
+		return false
+	case board.Knight:
+		return (absRowDiff == 2 && absColDiff == 1) || (absRowDiff == 1 && absColDiff == 2)
+	case board.Bishop:
+		return absRowDiff == absColDiff && g.referenceClearPath(from, to)
+	case board.Rook:
+		return (from.Row == to.Row || from.Col == to.Col) && g.referenceClearPath(from, to)
+	case board.Queen:
+		if from.Row != to.Row && from.Col != to.Col && absRowDiff != absColDiff {
+			return false
+		}
+		return g.referenceClearPath(from, to)
+	case board.King:
+		if absRowDiff == 0 && absColDiff == 2 {
+			return g.referenceCastling(from, to)
+		}
+		return absRowDiff <= 1 && absColDiff <= 1
+	}
+	return false
+}
+
+// referenceCastling checks the castling rights, that the king and rook are
+// on their starting squares, the path to the rook and that the king does
+// not start on, cross or land on an attacked square
+func (g *Game) referenceCastling(from, to board.Position) bool {
+	color := g.Board.GetPiece(from).Color
+	rights := g.pos.Castling()
+	homeRow := 7
+	if color == board.Black {
+		homeRow = 0
+	}
+	if from != (board.Position{Row: homeRow, Col: 4}) {
+		return false
+	}
+
+	rookCol := 0
+	if to.Col > from.Col {
+		if rights&bitboard.KingSide(color) == 0 {
+			return false
+		}
+		rookCol = 7
+	} else if rights&bitboard.QueenSide(color) == 0 {
+		return false
+	}
+
+	rookPos := board.Position{Row: from.Row, Col: rookCol}
+	if g.Board.GetPiece(rookPos) != (board.Piece{Type: board.Rook, Color: color}) {
+		return false
+	}
+	if !g.referenceClearPath(from, rookPos) {
+		return false
+	}
+
+	colDir := sign(to.Col - from.Col)
+	for col := from.Col; col != to.Col+colDir; col += colDir {
+		if g.referenceAttacked(board.Position{Row: homeRow, Col: col}, opponentOf(color)) {
+			return false
+		}
+	}
+	return true
+}
+
+// referenceAttacked checks if any piece of the given color attacks pos
+func (g *Game) referenceAttacked(pos board.Position, by board.Color) bool {
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			from := board.Position{Row: row, Col: col}
+			p := g.Board.GetPiece(from)
+			if p.Type == board.Empty || p.Color != by || from == pos {
+				continue
+			}
+
+			rowDiff := pos.Row - from.Row
+			absColDiff := abs(pos.Col - from.Col)
+			switch p.Type {
+			case board.Pawn:
+				forwardDir := -1
+				if by == board.Black {
+					forwardDir = 1
+				}
+				if rowDiff == forwardDir && absColDiff == 1 {
+					return true
+				}
+			case board.King:
+				if abs(rowDiff) <= 1 && absColDiff <= 1 {
+					return true
+				}
+			default:
+				saved := g.Board.GetPiece(pos)
+				g.Board.SetPiece(pos, board.Piece{Type: board.Pawn, Color: opponentOf(by)})
+				attacked := g.referenceValidMove(from, pos)
+				g.Board.SetPiece(pos, saved)
+				if attacked {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// referenceClearPath checks if there are no pieces between from and to
+func (g *Game) referenceClearPath(from, to board.Position) bool {
+	rowDir := sign(to.Row - from.Row)
+	colDir := sign(to.Col - from.Col)
+
+	row, col := from.Row+rowDir, from.Col+colDir
+	for row != to.Row || col != to.Col {
+		if !g.Board.IsEmpty(board.Position{Row: row, Col: col}) {
+			return false
+		}
+		row += rowDir
+		col += colDir
+	}
+	return true
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+// sortedSquares returns the squares as sorted algebraic strings
+func sortedSquares(squares []board.Position) []string {
+	names := make([]string, 0, len(squares))
+	for _, square := range squares {
+		names = append(names, square.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// referenceLegalMove checks a move with referenceValidMove and then that
+// it does not leave the player's own king attacked
+func (g *Game) referenceLegalMove(from, to board.Position) bool {
+	if !g.referenceValidMove(from, to) {
+		return false
+	}
+
+	saved := *g.Board
+	defer func() { *g.Board = saved }()
+	p := g.Board.GetPiece(from)
+	if p.Type == board.Pawn && from.Col != to.Col && g.Board.IsEmpty(to) {
+		// En passant takes the pawn beside the capturing one
+		g.Board.SetPiece(board.Position{Row: from.Row, Col: to.Col}, board.Piece{})
+	}
+	g.Board.MovePiece(from, to)
+
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			pos := board.Position{Row: row, Col: col}
+			if g.Board.GetPiece(pos) == (board.Piece{Type: board.King, Color: p.Color}) {
+				return !g.referenceAttacked(pos, opponentOf(p.Color))
+			}
+		}
+	}
+	return false
+}
+
+// compareRules checks every piece of the side to move against the
+// reference: the moves of its registered validator, and its legal moves
+// as LegalMovesFrom and IsValidMove give them
+func compareRules(t *testing.T, g *Game) {
+	t.Helper()
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			from := board.Position{Row: row, Col: col}
+			p := g.Board.GetPiece(from)
+			if p.Type == board.Empty || p.Color != g.CurrentPlayer {
+				continue
+			}
+
+			var valid, want []board.Position
+			for toRow := 0; toRow < 8; toRow++ {
+				for toCol := 0; toCol < 8; toCol++ {
+					to := board.Position{Row: toRow, Col: toCol}
+					if g.referenceValidMove(from, to) {
+						valid = append(valid, to)
+					}
+					if g.referenceLegalMove(from, to) {
+						want = append(want, to)
+					}
+					if g.IsValidMove(from, to) != g.referenceLegalMove(from, to) {
+						t.Fatalf("IsValidMove(%s, %s) = %v, reference says %v", from, to, g.IsValidMove(from, to), !g.IsValidMove(from, to))
+					}
+				}
+			}
+
+			validNames := sortedSquares(piece.GetValidMoves(from, g.Board, g.pieceState()))
+			if wantNames := sortedSquares(valid); !equalStrings(validNames, wantNames) {
+				t.Fatalf("moves from %s differ:\npiece:     %v\nreference: %v", from, validNames, wantNames)
+			}
+
+			// Promotions are listed once per piece, but count once here
+			seen := make(map[board.Position]bool)
+			var got []board.Position
+			for _, move := range g.LegalMovesFrom(from) {
+				if !seen[move.To] {
+					seen[move.To] = true
+					got = append(got, move.To)
+				}
+			}
+
+			if gotNames, wantNames := sortedSquares(got), sortedSquares(want); !equalStrings(gotNames, wantNames) {
+				t.Fatalf("moves from %s differ:\nbitboard:  %v\nreference: %v", from, gotNames, wantNames)
+			}
+		}
+	}
+}
+
+func TestPieceRulesMatchReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		g := NewGame()
+		g.TimeControl = nil
+
+		for ply := 0; ply < 120; ply++ {
+			compareRules(t, g)
+
+			moves := g.LegalMoves()
+			if len(moves) == 0 || (g.State != InProgress && g.State != Check) {
+				break
+			}
+			move := moves[rng.Intn(len(moves))]
+			if err := g.MakeMove(move.From, move.To); err != nil {
+				t.Fatalf("MakeMove(%s, %s) failed: %v", move.From, move.To, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// SAN returns the Standard Algebraic Notation of a move in the current
+// position, such as "Nbd7", "exd5", "O-O-O", "e8=Q#" or the drop "N@f3"
+func (g *Game) SAN(m Move) (string, error) {
+	if m.Drop != board.Empty {
+		move, ok := g.findLegalDrop(m.Drop, m.To)
+		if !ok {
+			return "", fmt.Errorf("illegal drop %s", dropNotation(m))
+		}
+		return g.san(move), nil
+	}
+	promotion := m.PromotionType
+	if promotion == board.Empty {
+		promotion = board.Queen
+	}
+	move, ok := g.findLegalMove(m.From, m.To, promotion)
+	if !ok {
+		return "", fmt.Errorf("illegal move %s%s", m.From, m.To)
+	}
+	return g.san(move), nil
+}
+
+// ParseMove returns the legal move written in Standard Algebraic Notation
+// ("Nf3", "exd5", "O-O-O", "e8=N", the drop "N@f3") or in coordinate
+// notation ("e2e4", "e2 e4", "e2-e4", "e7e8q"). A missing promotion piece
+// defaults to a queen.
+func (g *Game) ParseMove(text string) (Move, error) {
+	text = strings.TrimSpace(text)
+	if move, ok, err := g.parseCoordinates(text); ok {
+		return move, err
+	}
+	return g.parseSAN(text)
+}
+
+// parseCoordinates parses a move given as origin and destination squares
+// with an optional promotion piece. The boolean result reports whether the
+// text is in coordinate notation at all.
+func (g *Game) parseCoordinates(text string) (Move, bool, error) {
+	squares := strings.NewReplacer(" ", "", "-", "", "x", "", "=", "").Replace(text)
+	if len(squares) != 4 && len(squares) != 5 {
+		return Move{}, false, nil
+	}
+
+	from, err := board.NewPosition(squares[:2])
+	if err != nil {
+		return Move{}, false, nil
+	}
+	to, err := board.NewPosition(squares[2:4])
+	if err != nil {
+		return Move{}, false, nil
+	}
+
+	promotion := board.Queen
+	if len(squares) == 5 {
+		p, err := board.ParsePiece(rune(squares[4]))
+		if err != nil || p.Type == board.Pawn {
+			return Move{}, true, fmt.Errorf("invalid promotion in %q", text)
+		}
+		promotion = p.Type
+	}
+
+	move, ok := g.findLegalMove(from, to, promotion)
+	if !ok {
+		return Move{}, true, fmt.Errorf("%w %q", errIllegalMove, text)
+	}
+	return move, true, nil
+}
+
+// san returns the Standard Algebraic Notation of a legal move in the
+// current position, including the check or checkmate suffix
+func (g *Game) san(m Move) string {
+	var sb strings.Builder
+	piece := g.Board.GetPiece(m.From)
+
+	castled := g.castlingRight(m)
+	switch {
+	case m.Drop != board.Empty:
+		sb.WriteString(dropNotation(m))
+	case castled&(bitboard.WhiteKingSide|bitboard.BlackKingSide) != 0:
+		sb.WriteString("O-O")
+	case castled != bitboard.NoCastling:
+		sb.WriteString("O-O-O")
+	default:
+		capture := !g.Board.IsEmpty(m.To) || g.isEnPassant(m)
+
+		if piece.Type == board.Pawn {
+			if capture {
+				sb.WriteByte(m.From.String()[0])
+			}
+		} else {
+			sb.WriteString(board.Piece{Type: piece.Type, Color: board.White}.ASCIIString())
+			sb.WriteString(g.disambiguation(m))
+		}
+
+		if capture {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(m.To.String())
+
+		if m.PromotionType != board.Empty {
+			sb.WriteByte('=')
+			sb.WriteString(board.Piece{Type: m.PromotionType, Color: board.White}.ASCIIString())
+		}
+	}
+
+	// The suffix marks a move that gives check, even when a variant rule
+	// ends the game with it, as with the third check in Three-check
+	after := g.clone()
+	after.playMove(m)
+	if after.pos.InCheck(after.CurrentPlayer) {
+		if after.State == Checkmate {
+			sb.WriteByte('#')
+		} else {
+			sb.WriteByte('+')
+		}
+	}
+
+	return sb.String()
+}
+
+// disambiguation returns the file, rank or square needed to tell the move
+// apart from moves of other pieces of the same type to the same square
+func (g *Game) disambiguation(m Move) string {
+	piece := g.Board.GetPiece(m.From)
+	sameFile, sameRank, ambiguous := false, false, false
+
+	for _, other := range g.LegalMoves() {
+		from := other.From
+		if other.Drop != board.Empty || from == m.From || other.To != m.To || g.Board.GetPiece(from) != piece {
+			continue
+		}
+		ambiguous = true
+		if from.Col == m.From.Col {
+			sameFile = true
+		}
+		if from.Row == m.From.Row {
+			sameRank = true
+		}
+	}
+
+	square := m.From.String()
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return square[:1]
+	case !sameRank:
+		return square[1:]
+	default:
+		return square
+	}
+}
+
+// parseSAN returns the legal move written in Standard Algebraic Notation.
+// Check, mate and annotation suffixes are ignored, and a missing promotion
+// piece defaults to a queen.
+func (g *Game) parseSAN(san string) (Move, error) {
+	text := strings.TrimRight(san, "+#!?")
+	if strings.Contains(text, "@") {
+		return g.parseDrop(san, text)
+	}
+
+	switch text {
+	case "O-O", "0-0":
+		return g.findCastling(san, true)
+	case "O-O-O", "0-0-0":
+		return g.findCastling(san, false)
+	}
+
+	pieceType := board.Pawn
+	if text != "" && strings.ContainsRune("NBRQK", rune(text[0])) {
+		p, _ := board.ParsePiece(rune(text[0]))
+		pieceType = p.Type
+		text = text[1:]
+	}
+
+	promotion := board.Empty
+	if i := strings.IndexByte(text, '='); i >= 0 {
+		if len(text) != i+2 {
+			return Move{}, fmt.Errorf("invalid promotion in %q", san)
+		}
+		p, err := board.ParsePiece(rune(text[i+1]))
+		if err != nil || p.Color != board.White || p.Type == board.Pawn {
+			return Move{}, fmt.Errorf("invalid promotion in %q", san)
+		}
+		promotion = p.Type
+		text = text[:i]
+	} else if pieceType == board.Pawn && len(text) > 2 && strings.ContainsRune("NBRQK", rune(text[len(text)-1])) {
+		// Promotion written without the equals sign, as in "e8Q"
+		p, _ := board.ParsePiece(rune(text[len(text)-1]))
+		promotion = p.Type
+		text = text[:len(text)-1]
+	}
+
+	if len(text) < 2 {
+		return Move{}, fmt.Errorf("invalid move %q", san)
+	}
+	to, err := board.NewPosition(text[len(text)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move %q: %v", san, err)
+	}
+
+	// What is left is an optional origin file and rank and a capture mark
+	fromFile, fromRank := -1, -1
+	for _, r := range strings.TrimRight(text[:len(text)-2], "x:") {
+		switch {
+		case r >= 'a' && r <= 'h' && fromFile < 0 && fromRank < 0:
+			fromFile = int(r - 'a')
+		case r >= '1' && r <= '8' && fromRank < 0:
+			fromRank = int('8' - r)
+		default:
+			return Move{}, fmt.Errorf("invalid move %q", san)
+		}
+	}
+
+	if pieceType == board.Pawn && promotion == board.Empty && (to.Row == 0 || to.Row == 7) {
+		promotion = board.Queen
+	}
+
+	var matches []Move
+	for _, move := range g.LegalMoves() {
+		if move.Drop != board.Empty {
+			continue
+		}
+		p := g.Board.GetPiece(move.From)
+		if p.Type != pieceType || move.To != to || move.PromotionType != promotion {
+			continue
+		}
+		if (fromFile >= 0 && move.From.Col != fromFile) || (fromRank >= 0 && move.From.Row != fromRank) {
+			continue
+		}
+		if g.castlingRight(move) != bitboard.NoCastling {
+			continue
+		}
+		matches = append(matches, move)
+	}
+
+	switch len(matches) {
+	case 0:
+		return Move{}, fmt.Errorf("%w %q", errIllegalMove, san)
+	case 1:
+		return matches[0], nil
+	default:
+		return Move{}, fmt.Errorf("ambiguous move %q", san)
+	}
+}
+
+// findCastling returns the legal castling move on the king's side or the
+// queen's side
+func (g *Game) findCastling(san string, kingSide bool) (Move, error) {
+	want := bitboard.QueenSide(g.CurrentPlayer)
+	if kingSide {
+		want = bitboard.KingSide(g.CurrentPlayer)
+	}
+	for _, move := range g.LegalMoves() {
+		if g.castlingRight(move) == want {
+			return move, nil
+		}
+	}
+	return Move{}, fmt.Errorf("%w %q", errIllegalMove, san)
+}
+
+// dropNotation returns a drop as the piece letter, "@" and the square, as
+// in "N@f3" or "P@e4"
+func dropNotation(m Move) string {
+	return board.Piece{Type: m.Drop, Color: board.White}.ASCIIString() + "@" + m.To.String()
+}
+
+// parseDrop returns the legal drop written as text, such as "N@f3". Pawn
+// drops may leave out the piece letter, as in "@e4".
+func (g *Game) parseDrop(san, text string) (Move, error) {
+	letter, square, _ := strings.Cut(text, "@")
+	pieceType := board.Pawn
+	if letter != "" {
+		p, err := board.ParsePiece(rune(strings.ToUpper(letter)[0]))
+		if err != nil || len(letter) != 1 || p.Type == board.King {
+			return Move{}, fmt.Errorf("invalid drop %q", san)
+		}
+		pieceType = p.Type
+	}
+	to, err := board.NewPosition(square)
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid drop %q: %v", san, err)
+	}
+
+	move, ok := g.findLegalDrop(pieceType, to)
+	if !ok {
+		return Move{}, fmt.Errorf("illegal drop %q", san)
+	}
+	return move, nil
+}
@@ -0,0 +1,158 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/user/chess/pkg/board"
+)
+
+// pos converts an algebraic square for test tables
+func pos(algebraic string) board.Position {
+	p, err := board.NewPosition(algebraic)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		variant Variant
+		move    Move
+		want    string
+	}{
+		{"pawn push", StartFEN, Standard, Move{From: pos("e2"), To: pos("e4")}, "e4"},
+		{"knight", StartFEN, Standard, Move{From: pos("g1"), To: pos("f3")}, "Nf3"},
+		{"pawn capture", "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", Standard, Move{From: pos("e4"), To: pos("d5")}, "exd5"},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", Standard, Move{From: pos("e5"), To: pos("d6")}, "exd6"},
+		{"file disambiguation", "4k3/8/8/8/8/8/6K1/R6R w - - 0 1", Standard, Move{From: pos("a1"), To: pos("d1")}, "Rad1"},
+		{"rank disambiguation", "4k3/8/R7/8/8/8/8/R3K3 w - - 0 1", Standard, Move{From: pos("a1"), To: pos("a3")}, "R1a3"},
+		{"square disambiguation", "8/7k/8/8/Q2Q4/8/8/Q5K1 w - - 0 1", Standard, Move{From: pos("a4"), To: pos("d1")}, "Qa4d1"},
+		{"file beats rank disambiguation", "8/7k/8/8/Q2Q4/8/8/Q5K1 w - - 0 1", Standard, Move{From: pos("d4"), To: pos("d1")}, "Qdd1"},
+		{"pinned piece needs no disambiguation", "4k3/8/8/b7/8/2N5/8/4K1N1 w - - 0 1", Standard, Move{From: pos("g1"), To: pos("e2")}, "Ne2"},
+		{"kingside castling", "5k2/8/8/8/8/8/8/4K2R w K - 0 1", Standard, Move{From: pos("e1"), To: pos("g1")}, "O-O+"},
+		{"queenside castling", "r3k3/8/8/8/8/8/8/4K3 b q - 0 1", Standard, Move{From: pos("e8"), To: pos("c8")}, "O-O-O"},
+		{"promotion", "8/4P3/8/8/8/8/8/k3K3 w - - 0 1", Standard, Move{From: pos("e7"), To: pos("e8"), PromotionType: board.Queen}, "e8=Q"},
+		{"capture promotion", "3r4/4P3/8/8/8/8/8/k3K3 w - - 0 1", Standard, Move{From: pos("e7"), To: pos("d8"), PromotionType: board.Knight}, "exd8=N"},
+		{"check", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", Standard, Move{From: pos("a1"), To: pos("a8")}, "Ra8+"},
+		{"checkmate", "6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", Standard, Move{From: pos("a1"), To: pos("a8")}, "Ra8#"},
+		{"check that wins in Three-check", "rnbqkbnr/ppp2ppp/8/3pp3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 1+3 0 3", ThreeCheck, Move{From: pos("f1"), To: pos("b5")}, "Bb5+"},
+		{"check that wins in King of the Hill", "8/8/8/8/8/R2K3k/8/8 w - - 0 1", KingOfTheHill, Move{From: pos("d3"), To: pos("d4")}, "Kd4+"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(tt.variant))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.san(tt.move); got != tt.want {
+				t.Errorf("san(%v-%v) = %q, want %q", tt.move.From, tt.move.To, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	tests := []struct {
+		fen   string
+		input string
+		want  Move
+	}{
+		{StartFEN, "e4", Move{From: pos("e2"), To: pos("e4")}},
+		{StartFEN, "Nf3", Move{From: pos("g1"), To: pos("f3")}},
+		{StartFEN, "Nf3!?", Move{From: pos("g1"), To: pos("f3")}},
+		{StartFEN, "e2e4", Move{From: pos("e2"), To: pos("e4")}},
+		{StartFEN, "e2 e4", Move{From: pos("e2"), To: pos("e4")}},
+		{StartFEN, "g1-f3", Move{From: pos("g1"), To: pos("f3")}},
+		{"4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", "exd5", Move{From: pos("e4"), To: pos("d5")}},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6", Move{From: pos("e5"), To: pos("d6")}},
+		{"4k3/8/8/8/8/8/6K1/R6R w - - 0 1", "Rad1", Move{From: pos("a1"), To: pos("d1")}},
+		{"4k3/8/R7/8/8/8/8/R3K3 w - - 0 1", "R1a3", Move{From: pos("a1"), To: pos("a3")}},
+		{"8/7k/8/8/Q2Q4/8/8/Q5K1 w - - 0 1", "Qa4d1", Move{From: pos("a4"), To: pos("d1")}},
+		{"r3k3/8/8/8/8/8/8/4K2R w K - 0 1", "O-O", Move{From: pos("e1"), To: pos("g1")}},
+		{"r3k3/8/8/8/8/8/8/4K2R b q - 0 1", "O-O-O", Move{From: pos("e8"), To: pos("c8")}},
+		{"r3k3/8/8/8/8/8/8/4K2R b q - 0 1", "0-0-0", Move{From: pos("e8"), To: pos("c8")}},
+		{"8/4P3/8/8/8/8/8/k3K3 w - - 0 1", "e8=N", Move{From: pos("e7"), To: pos("e8"), PromotionType: board.Knight}},
+		{"8/4P3/8/8/8/8/8/k3K3 w - - 0 1", "e8", Move{From: pos("e7"), To: pos("e8"), PromotionType: board.Queen}},
+		{"8/4P3/8/8/8/8/8/k3K3 w - - 0 1", "e8R", Move{From: pos("e7"), To: pos("e8"), PromotionType: board.Rook}},
+		{"8/4P3/8/8/8/8/8/k3K3 w - - 0 1", "e7e8q", Move{From: pos("e7"), To: pos("e8"), PromotionType: board.Queen}},
+		{"8/4P3/8/8/8/8/8/k3K3 w - - 0 1", "e7e8b", Move{From: pos("e7"), To: pos("e8"), PromotionType: board.Bishop}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := g.ParseMove(tt.input)
+			if err != nil {
+				t.Fatalf("ParseMove(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMove(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoveErrors(t *testing.T) {
+	tests := []struct {
+		fen   string
+		input string
+	}{
+		{StartFEN, ""},
+		{StartFEN, "e5"},
+		{StartFEN, "Nf4"},
+		{StartFEN, "e2e5"},
+		{StartFEN, "O-O"},
+		{StartFEN, "hello"},
+		{"4k3/8/8/8/8/8/6K1/R6R w - - 0 1", "Rd1"},
+		{"8/4P3/8/8/8/8/8/k3K3 w - - 0 1", "e8=K"},
+		{"8/4P3/8/8/8/8/8/k3K3 w - - 0 1", "e7e8k"},
+	}
+
+	for _, tt := range tests {
+		g, err := NewGameFromFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if move, err := g.ParseMove(tt.input); err == nil {
+			t.Errorf("ParseMove(%q) = %+v, expected an error", tt.input, move)
+		}
+	}
+}
+
+func TestSANRoundTrip(t *testing.T) {
+	fens := []string{
+		StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"8/7k/8/8/Q2Q4/8/8/Q5K1 w - - 0 1",
+	}
+
+	for _, fen := range fens {
+		g, err := NewGameFromFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, move := range g.LegalMoves() {
+			san, err := g.SAN(move)
+			if err != nil {
+				t.Fatalf("SAN(%+v) error = %v", move, err)
+			}
+			parsed, err := g.ParseMove(san)
+			if err != nil {
+				t.Errorf("%s: ParseMove(%q) error = %v", fen, san, err)
+				continue
+			}
+			if parsed != move {
+				t.Errorf("%s: ParseMove(%q) = %+v, want %+v", fen, san, parsed, move)
+			}
+		}
+	}
+}
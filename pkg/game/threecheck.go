@@ -0,0 +1,96 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// threeCheck is won by checkmate or by giving check three times
+type threeCheck struct{ standard }
+
+// checksToWin is the number of checks that wins a game of Three-check
+const checksToWin = 3
+
+func (threeCheck) Name() string {
+	return "Three-check"
+}
+
+func (threeCheck) Setup(n int) bitboard.Position {
+	p := bitboard.StartPosition()
+	p.SetRules(p.Rules() | bitboard.CountChecks)
+	return p
+}
+
+func (threeCheck) Result(g *Game, result Result) Result {
+	for _, color := range []board.Color{board.White, board.Black} {
+		if g.pos.Checks(color) >= checksToWin {
+			return win(color, ReasonThreeChecks)
+		}
+	}
+
+	// Any piece besides the king can give check, but a lone king never
+	// can, and neither can kings behind a locked pawn chain
+	switch {
+	case result.Reason == ReasonDeadPosition,
+		result.Reason == ReasonInsufficientMaterial && g.hasOnlyKing(board.White) && g.hasOnlyKing(board.Black),
+		result.Reason == ReasonTimeoutVsInsufficientMaterial && g.hasOnlyKing(g.opponent()):
+		return result
+	}
+	return ignoreMaterialDraws(g, result)
+}
+
+// FormatFEN adds the number of checks each side still has to give, as in
+// "3+2", after the en passant square
+func (threeCheck) FormatFEN(p *bitboard.Position, fields []string) []string {
+	remaining := strconv.Itoa(checksToWin-p.Checks(board.White)) + "+" + strconv.Itoa(checksToWin-p.Checks(board.Black))
+	return append(fields[:4:4], append([]string{remaining}, fields[4:]...)...)
+}
+
+// ParseFEN reads the checks each side still has to give after the en
+// passant square, or the checks each side has given as a last field such
+// as "+1+0". A FEN with neither starts with no checks given.
+func (threeCheck) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	var given [3]int
+	if len(fields) > 4 {
+		if last := fields[len(fields)-1]; strings.HasPrefix(last, "+") {
+			white, black, ok := parseCheckCounts(last[1:])
+			if !ok {
+				return bitboard.Position{}, fmt.Errorf("invalid checks %q", last)
+			}
+			given[board.White], given[board.Black] = white, black
+			fields = fields[:len(fields)-1]
+		} else if white, black, ok := parseCheckCounts(fields[4]); ok {
+			given[board.White], given[board.Black] = checksToWin-white, checksToWin-black
+			fields = append(fields[:4:4], fields[5:]...)
+		}
+	}
+
+	p, err := parse(fields)
+	if err != nil {
+		return bitboard.Position{}, err
+	}
+	p.SetRules(p.Rules() | bitboard.CountChecks)
+	p.SetChecks(board.White, given[board.White])
+	p.SetChecks(board.Black, given[board.Black])
+	return p, nil
+}
+
+// parseCheckCounts reads two numbers of checks from 0 to 3 written as
+// "a+b"
+func parseCheckCounts(field string) (a, b int, ok bool) {
+	if len(field) != 3 || field[1] != '+' {
+		return 0, 0, false
+	}
+	a, b = int(field[0])-'0', int(field[2])-'0'
+	return a, b, a >= 0 && a <= checksToWin && b >= 0 && b <= checksToWin
+}
+
+// Checks returns the number of checks the player of the given color has
+// given. Only Three-check counts them; other variants return 0.
+func (g *Game) Checks(color board.Color) int {
+	return g.pos.Checks(color)
+}
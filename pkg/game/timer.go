@@ -46,6 +46,15 @@ func (tc *TimeControl) Stop() time.Duration {
 	return elapsed
 }
 
+// Elapsed returns the time since the running clock was started, or zero
+// if no clock is running
+func (tc *TimeControl) Elapsed() time.Duration {
+	if !tc.isRunning {
+		return 0
+	}
+	return time.Since(tc.lastMoveTime)
+}
+
 // SwitchPlayer switches the active timer and adds the increment
 func (tc *TimeControl) SwitchPlayer(isWhite bool) {
 	elapsed := tc.Stop()
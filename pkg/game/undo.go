@@ -0,0 +1,112 @@
+package game
+
+import (
+	"errors"
+	"time"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// snapshot is the state of a game at one point of its move history.
+// Undo and Redo restore snapshots instead of reversing moves, so nothing
+// a move changes can be forgotten.
+type snapshot struct {
+	pos           bitboard.Position
+	state         GameState
+	result        Result
+	drawOffer     board.Color
+	hasClock      bool
+	whiteTimeLeft time.Duration
+	blackTimeLeft time.Duration
+}
+
+// takenBack is a move removed by Undo together with the state after it
+type takenBack struct {
+	move  Move
+	after snapshot
+}
+
+// snapshot records the current state of the game
+func (g *Game) snapshot() snapshot {
+	s := snapshot{
+		pos:       g.pos,
+		state:     g.State,
+		result:    g.result,
+		drawOffer: g.drawOffer,
+	}
+	if g.TimeControl != nil {
+		s.hasClock = true
+		s.whiteTimeLeft = g.TimeControl.WhiteTimeLeft
+		s.blackTimeLeft = g.TimeControl.BlackTimeLeft
+	}
+	return s
+}
+
+// restore puts the game back into a recorded state. The clock is only
+// restored if it was running when the state was recorded.
+func (g *Game) restore(s snapshot) {
+	g.setPosition(s.pos)
+	g.State = s.state
+	g.result = s.result
+	g.drawOffer = s.drawOffer
+
+	if s.hasClock && g.TimeControl != nil {
+		g.TimeControl.WhiteTimeLeft = s.whiteTimeLeft
+		g.TimeControl.BlackTimeLeft = s.blackTimeLeft
+		if g.TimeControl.isRunning {
+			g.TimeControl.Start()
+		}
+	}
+}
+
+// CanUndo reports whether there is a move to take back
+func (g *Game) CanUndo() bool {
+	return len(g.undoStack) > 0
+}
+
+// CanRedo reports whether there is a taken back move to replay
+func (g *Game) CanRedo() bool {
+	return len(g.redoStack) > 0
+}
+
+// Undo takes back the last move, restoring the board, side to move,
+// castling rights, en passant target, move clocks, game state and the
+// time left on both clocks. The position is also removed from the
+// repetition history.
+func (g *Game) Undo() error {
+	if g.partner != nil {
+		return errors.New("moves cannot be taken back in a Bughouse match")
+	}
+	if !g.CanUndo() {
+		return errors.New("no move to undo")
+	}
+
+	last := len(g.undoStack) - 1
+	g.redoStack = append(g.redoStack, takenBack{
+		move:  g.moveHistory[len(g.moveHistory)-1],
+		after: g.snapshot(),
+	})
+	g.restore(g.undoStack[last])
+	g.undoStack = g.undoStack[:last]
+	g.moveHistory = g.moveHistory[:len(g.moveHistory)-1]
+	g.positionHashes = g.positionHashes[:len(g.positionHashes)-1]
+	return nil
+}
+
+// Redo replays the last move taken back with Undo. Playing a new move
+// discards the moves that could be redone.
+func (g *Game) Redo() error {
+	if !g.CanRedo() {
+		return errors.New("no move to redo")
+	}
+
+	last := len(g.redoStack) - 1
+	redo := g.redoStack[last]
+	g.undoStack = append(g.undoStack, g.snapshot())
+	g.restore(redo.after)
+	g.redoStack = g.redoStack[:last]
+	g.moveHistory = append(g.moveHistory, redo.move)
+	g.positionHashes = append(g.positionHashes, g.pos.Hash())
+	return nil
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/chess/pkg/board"
+)
+
+func TestUndoRestoresState(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+	}{
+		{"opening", StartFEN, []string{"e2 e4", "e7 e5", "g1 f3"}},
+		{"en passant", "4k3/3p4/8/4P3/8/8/8/4K3 b - - 0 1", []string{"d7 d5", "e5 d6"}},
+		{"castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 3 10", []string{"e1 g1", "e8 c8"}},
+		{"rook capture", "r3k2r/8/8/8/8/8/6b1/R3K2R b KQkq - 0 1", []string{"g2 h1"}},
+		{"promotion", "8/4P1k1/8/8/8/8/8/4K3 w - - 5 60", []string{"e7 e8"}},
+		{"checkmate", StartFEN, []string{"f2 f3", "e7 e5", "g2 g4", "d8 h4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+
+			var fens []string
+			var states []GameState
+			for _, move := range tt.moves {
+				fens = append(fens, g.FEN())
+				states = append(states, g.State)
+				playMoves(t, g, move)
+			}
+			final, finalState := g.FEN(), g.State
+
+			for i := len(tt.moves) - 1; i >= 0; i-- {
+				if err := g.Undo(); err != nil {
+					t.Fatalf("Undo() failed: %v", err)
+				}
+				if g.FEN() != fens[i] || g.State != states[i] {
+					t.Errorf("after undo FEN = %q (%v), want %q (%v)", g.FEN(), g.State, fens[i], states[i])
+				}
+			}
+			if err := g.Undo(); err == nil {
+				t.Error("Undo() with no moves left succeeded")
+			}
+
+			for range tt.moves {
+				if err := g.Redo(); err != nil {
+					t.Fatalf("Redo() failed: %v", err)
+				}
+			}
+			if g.FEN() != final || g.State != finalState {
+				t.Errorf("after redo FEN = %q (%v), want %q (%v)", g.FEN(), g.State, final, finalState)
+			}
+			if len(g.moveHistory) != len(tt.moves) {
+				t.Errorf("history has %d moves, want %d", len(g.moveHistory), len(tt.moves))
+			}
+			if err := g.Redo(); err == nil {
+				t.Error("Redo() with no moves left succeeded")
+			}
+		})
+	}
+}
+
+func TestNewMoveClearsRedo(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	playMoves(t, g, "e2 e4", "e7 e5")
+
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, g, "c7 c5")
+	if g.CanRedo() {
+		t.Error("CanRedo() after a new move")
+	}
+	if err := g.Redo(); err == nil {
+		t.Error("Redo() after a new move succeeded")
+	}
+
+	var notations []string
+	for _, move := range g.moveHistory {
+		notations = append(notations, move.Notation)
+	}
+	if want := []string{"e4", "c5"}; !equalStrings(notations, want) {
+		t.Errorf("history = %v, want %v", notations, want)
+	}
+}
+
+func TestUndoRestoresClock(t *testing.T) {
+	g := NewGame()
+	g.TimeControl.Start()
+	g.TimeControl.WhiteTimeLeft = 3 * time.Minute
+	g.TimeControl.BlackTimeLeft = 2 * time.Minute
+
+	playMoves(t, g, "e2 e4")
+	afterMove := g.TimeControl.WhiteTimeLeft
+	if afterMove == 3*time.Minute {
+		t.Fatal("increment was not added")
+	}
+
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if g.TimeControl.WhiteTimeLeft != 3*time.Minute || g.TimeControl.BlackTimeLeft != 2*time.Minute {
+		t.Errorf("clocks = %v/%v after undo, want 3m0s/2m0s", g.TimeControl.WhiteTimeLeft, g.TimeControl.BlackTimeLeft)
+	}
+	if g.CurrentPlayer != board.White {
+		t.Errorf("CurrentPlayer = %v after undo, want White", g.CurrentPlayer)
+	}
+
+	if err := g.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if g.TimeControl.WhiteTimeLeft != afterMove {
+		t.Errorf("white clock = %v after redo, want %v", g.TimeControl.WhiteTimeLeft, afterMove)
+	}
+}
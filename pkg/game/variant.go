@@ -0,0 +1,277 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// Variant is a set of rules that differ from standard chess. A variant can
+// change the starting position, the moves a player may make, the ways the
+// game ends and how positions are written. Variants that change how the
+// pieces move or capture set the matching bitboard.Rules on the positions
+// they create in Setup and ParseFEN, so that the move generator follows
+// them.
+//
+// The variants of this package embed the unexported standard type and
+// override only the methods they change. A variant written elsewhere can do
+// the same by embedding the Variant interface set to Standard, as in
+// myVariant{Variant: game.Standard}.
+type Variant interface {
+	// Name returns the name of the variant as written in the PGN Variant tag
+	Name() string
+
+	// Setup returns the position a new game starts from. n chooses one of
+	// several starting positions, or is negative for a random one;
+	// variants with a single starting position ignore it.
+	Setup(n int) bitboard.Position
+
+	// LegalMoves returns the moves the player to move may make in p.
+	// Standard takes them from p.LegalMoves, which follows the rules set on
+	// p; a variant may filter that list, add moves of its own or generate
+	// them some other way, as long as p.MakeMove can play them. It may
+	// make and take back moves on p.
+	LegalMoves(p *bitboard.Position) []bitboard.Move
+
+	// Result returns the result of the game after a move, or when the
+	// clock of the player to move runs out. standard is the result under
+	// the standard rules, with an Ongoing outcome if they do not end the
+	// game; returning it unchanged keeps those rules.
+	Result(g *Game, standard Result) Result
+
+	// FormatFEN returns the fields of the FEN of p, given the fields of
+	// standard FEN
+	FormatFEN(p *bitboard.Position, fields []string) []string
+
+	// ParseFEN reads the position written in the fields of a FEN. parse
+	// reads the fields of standard FEN; the variant takes its own
+	// additions out before calling it and applies them to the result.
+	ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error)
+}
+
+// The built-in variants
+var (
+	Standard      Variant = standard{}
+	Chess960      Variant = chess960{}
+	KingOfTheHill Variant = kingOfTheHill{}
+	ThreeCheck    Variant = threeCheck{}
+	Crazyhouse    Variant = crazyhouse{}
+	Atomic        Variant = atomic{}
+	Antichess     Variant = antichess{}
+	Horde         Variant = horde{}
+	RacingKings   Variant = racingKings{}
+	Kriegspiel    Variant = kriegspiel{}
+	FogOfWar      Variant = fogOfWar{}
+	Bughouse      Variant = bughouse{}
+)
+
+// variants holds the registered variants by normalized name, and
+// variantOrder their order of registration
+var (
+	variants     = map[string]Variant{}
+	variantOrder []Variant
+)
+
+func init() {
+	RegisterVariant(Standard, "chess")
+	RegisterVariant(Chess960, "960", "fischer random", "fischerandom")
+	RegisterVariant(KingOfTheHill, "koth")
+	RegisterVariant(ThreeCheck, "3check", "three check")
+	RegisterVariant(Crazyhouse, "zh")
+	RegisterVariant(Atomic)
+	RegisterVariant(Antichess, "losing chess")
+	RegisterVariant(Horde)
+	RegisterVariant(RacingKings, "racing")
+	RegisterVariant(Kriegspiel)
+	RegisterVariant(FogOfWar, "fog", "dark chess")
+	RegisterVariant(Bughouse, "bughouse chess", "tandem")
+}
+
+// RegisterVariant makes a variant available to ParseVariant under its
+// name and the given aliases
+func RegisterVariant(v Variant, aliases ...string) {
+	if _, ok := variants[variantKey(v.Name())]; !ok {
+		variantOrder = append(variantOrder, v)
+	}
+	for _, name := range append([]string{v.Name()}, aliases...) {
+		variants[variantKey(name)] = v
+	}
+}
+
+// Variants returns the registered variants in the order they were registered
+func Variants() []Variant {
+	return append([]Variant(nil), variantOrder...)
+}
+
+// ParseVariant looks up a registered variant by name or alias, ignoring
+// case, spaces and hyphens. An empty name is standard chess.
+func ParseVariant(name string) (Variant, error) {
+	if strings.TrimSpace(name) == "" {
+		return Standard, nil
+	}
+	if v, ok := variants[variantKey(name)]; ok {
+		return v, nil
+	}
+	return Standard, fmt.Errorf("unknown variant %q", name)
+}
+
+// variantKey normalizes a variant name for lookup
+func variantKey(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+// Option configures a game created with NewGame
+type Option func(*options)
+
+type options struct {
+	variant       Variant
+	startPosition int // Starting position number, or -1 for a random one
+}
+
+// newOptions applies opts to the defaults: a standard game from a random
+// starting position, where the variant has several
+func newOptions(opts []Option) options {
+	o := options{variant: Standard, startPosition: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithVariant sets the variant of the game. Chess960 games start from a
+// random position unless WithStartPosition chooses one.
+func WithVariant(v Variant) Option {
+	return func(o *options) {
+		o.variant = v
+	}
+}
+
+// WithStartPosition chooses the Chess960 starting position by its number,
+// from 0 to 959. It has no effect on other variants.
+func WithStartPosition(n int) Option {
+	return func(o *options) {
+		o.startPosition = n
+	}
+}
+
+// standardPosition is the number of the standard starting position among
+// the Chess960 ones
+const standardPosition = 518
+
+// newGameFrom creates a game of a variant that starts from a FEN position,
+// or from the standard starting position if fen is empty
+func newGameFrom(variant Variant, fen string) (*Game, error) {
+	if fen == "" {
+		return NewGame(WithVariant(variant), WithStartPosition(standardPosition)), nil
+	}
+	return NewGameFromFEN(fen, WithVariant(variant))
+}
+
+// Variant returns the variant the game is played as
+func (g *Game) Variant() Variant {
+	return g.variant
+}
+
+// standard is the plain game of chess
+type standard struct{}
+
+func (standard) Name() string {
+	return "Standard"
+}
+
+func (standard) Setup(n int) bitboard.Position {
+	return bitboard.StartPosition()
+}
+
+func (standard) LegalMoves(p *bitboard.Position) []bitboard.Move {
+	return p.LegalMoves()
+}
+
+func (standard) Result(g *Game, standard Result) Result {
+	return standard
+}
+
+func (standard) FormatFEN(p *bitboard.Position, fields []string) []string {
+	return fields
+}
+
+func (standard) ParseFEN(fields []string, parse func([]string) (bitboard.Position, error)) (bitboard.Position, error) {
+	return parse(fields)
+}
+
+// ignoreMaterialDraws takes back the draws the standard rules give for
+// material that could never mate, for variants where the game can be won
+// without mating: the game goes on, and running out of time loses.
+func ignoreMaterialDraws(g *Game, result Result) Result {
+	switch result.Reason {
+	case ReasonInsufficientMaterial, ReasonDeadPosition:
+		return Result{}
+	case ReasonTimeoutVsInsufficientMaterial:
+		return win(g.opponent(), ReasonTimeout)
+	}
+	return result
+}
+
+// chess960 is Fischer Random chess: the pieces start on a shuffled back
+// rank and castling brings the king and rook to the usual squares
+type chess960 struct{ standard }
+
+func (chess960) Name() string {
+	return "Chess960"
+}
+
+func (chess960) Setup(n int) bitboard.Position {
+	if n < 0 {
+		n = randomChess960Position()
+	}
+	return chess960Position(n)
+}
+
+// chess960Position returns Chess960 starting position n with both sides
+// able to castle with either rook
+func chess960Position(n int) bitboard.Position {
+	p := bitboard.NewPosition(board.NewChess960Board(n), board.White)
+	p.SetRules(p.Rules() | bitboard.Chess960)
+	for _, right := range []bitboard.CastlingRights{
+		bitboard.WhiteKingSide, bitboard.WhiteQueenSide, bitboard.BlackKingSide, bitboard.BlackQueenSide,
+	} {
+		p.SetCastlingRook(right, outermostRook(&p, right))
+	}
+	p.SetCastling(bitboard.AllCastling)
+	return p
+}
+
+// randomChess960Position returns the number of a random Chess960 starting
+// position
+func randomChess960Position() int {
+	return rand.Intn(board.Chess960Positions)
+}
+
+// outermostRook returns the rook furthest from the king on the side of a
+// castling right, or NoSquare if there is none
+func outermostRook(p *bitboard.Position, right bitboard.CastlingRights) bitboard.Square {
+	color := board.White
+	if right&(bitboard.BlackKingSide|bitboard.BlackQueenSide) != 0 {
+		color = board.Black
+	}
+	row := bitboard.HomeRow(color)
+	king := p.King(color)
+	if king == bitboard.NoSquare || king.Row() != row {
+		return bitboard.NoSquare
+	}
+
+	rook := board.Piece{Type: board.Rook, Color: color}
+	col, step := 7, -1
+	if right == bitboard.QueenSide(color) {
+		col, step = 0, 1
+	}
+	for ; col != king.Col(); col += step {
+		if s := bitboard.SquareAt(row, col); p.Piece(s) == rook {
+			return s
+		}
+	}
+	return bitboard.NoSquare
+}
@@ -0,0 +1,1129 @@
+package game
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/pgn"
+)
+
+func TestNewGameChess960(t *testing.T) {
+	tests := []struct {
+		n   int
+		fen string
+	}{
+		{0, "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"},
+		{518, StartFEN},
+		{959, "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w KQkq - 0 1"},
+	}
+
+	for _, tt := range tests {
+		g := NewGame(WithVariant(Chess960), WithStartPosition(tt.n))
+		if g.Variant() != Chess960 {
+			t.Errorf("position %d: Variant() = %s, want Chess960", tt.n, g.Variant().Name())
+		}
+		if got := g.FEN(); got != tt.fen {
+			t.Errorf("position %d: FEN() = %q, want %q", tt.n, got, tt.fen)
+		}
+	}
+
+	// Random positions always allow castling on both sides
+	for i := 0; i < 20; i++ {
+		g := NewGame(WithVariant(Chess960))
+		if got := strings.Fields(g.FEN())[2]; got != "KQkq" {
+			t.Fatalf("random position %s has castling rights %q", g.FEN(), got)
+		}
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	tests := []struct {
+		name          string
+		fen           string
+		wantKingSide  bool
+		wantQueenSide bool
+	}{
+		{"both sides free", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w KQkq - 0 1", true, true},
+		{"king next to its rook", "4k3/8/8/8/8/8/8/5KR1 w K - 0 1", true, false},
+		{"king already on its square", "4k3/8/8/8/8/8/8/6KR w K - 0 1", true, false},
+		{"piece on the rook's path", "4k3/8/8/8/8/8/8/RN2K3 w Q - 0 1", false, false},
+		{"piece on the rook's square", "4k3/8/8/8/8/8/8/RK1N4 w Q - 0 1", false, false},
+		{"king crosses an attacked square", "4r1k1/8/8/8/8/8/8/1K5R w K - 0 1", false, false},
+		{"rook lands on an attacked square", "3r2k1/8/8/8/8/8/8/RK6 w Q - 0 1", false, true},
+		{"rook uncovers an attack on the king", "4k3/8/8/8/8/8/8/qRK5 w Q - 0 1", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(Chess960))
+			if err != nil {
+				t.Fatal(err)
+			}
+			kingSide, queenSide := castlingMoves(g)
+			if kingSide != tt.wantKingSide || queenSide != tt.wantQueenSide {
+				t.Errorf("castling = (%v, %v), want (%v, %v)", kingSide, queenSide, tt.wantKingSide, tt.wantQueenSide)
+			}
+		})
+	}
+}
+
+func TestChess960CastlingMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		input string
+		want  string
+	}{
+		{"kingside", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w KQkq - 0 1", "O-O", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R3RK1 b kq - 1 1"},
+		{"queenside", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w KQkq - 0 1", "O-O-O", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/2KR2R1 b kq - 1 1"},
+		{"king onto its rook", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w KQkq - 0 1", "e1g1", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R3RK1 b kq - 1 1"},
+		{"king to its destination", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w KQkq - 0 1", "e1c1", "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/2KR2R1 b kq - 1 1"},
+		{"king and rook swap", "4k3/8/8/8/8/8/8/5KR1 w K - 0 1", "O-O", "4k3/8/8/8/8/8/8/5RK1 b - - 1 1"},
+		{"king stays", "4k3/8/8/8/8/8/8/6KR w K - 0 1", "g1h1", "4k3/8/8/8/8/8/8/5RK1 b - - 1 1"},
+		{"black queenside", "rk2r3/8/8/8/8/8/8/7K b kq - 0 1", "O-O-O", "2krr3/8/8/8/8/8/8/7K w - - 1 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(Chess960))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			move, err := g.ParseMove(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := g.PlayMove(move); err != nil {
+				t.Fatal(err)
+			}
+			if got := g.FEN(); got != tt.want {
+				t.Errorf("FEN() = %q, want %q", got, tt.want)
+			}
+			if err := g.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := g.FEN(); got != tt.fen {
+				t.Errorf("FEN() after Undo = %q, want %q", got, tt.fen)
+			}
+		})
+	}
+}
+
+func TestChess960CastlingNotation(t *testing.T) {
+	g, err := NewGameFromFEN("1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w KQkq - 0 1", WithVariant(Chess960))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "e1 g1", "e8 b8")
+	if got := g.moveHistory[0].Notation + " " + g.moveHistory[1].Notation; got != "O-O O-O-O" {
+		t.Errorf("notation = %q, want %q", got, "O-O O-O-O")
+	}
+}
+
+func TestChess960FEN(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		want     string // FEN() of the position, X-FEN
+		shredder string // The castling field of ShredderFEN()
+	}{
+		{"shredder", "1r2k1r1/8/8/8/8/8/8/1R2K1R1 w GBgb - 0 1", "1r2k1r1/8/8/8/8/8/8/1R2K1R1 w KQkq - 0 1", "GBgb"},
+		{"inner rook", "r2k1rr1/8/8/8/8/8/8/R2K1RR1 w FAf - 0 1", "r2k1rr1/8/8/8/8/8/8/R2K1RR1 w FQf - 0 1", "FAf"},
+		{"outer rook", "r2k1rr1/8/8/8/8/8/8/R2K1RR1 w KQ - 0 1", "r2k1rr1/8/8/8/8/8/8/R2K1RR1 w KQ - 0 1", "GA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(Chess960))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.FEN(); got != tt.want {
+				t.Errorf("FEN() = %q, want %q", got, tt.want)
+			}
+			if got := strings.Fields(g.ShredderFEN())[2]; got != tt.shredder {
+				t.Errorf("ShredderFEN() castling = %q, want %q", got, tt.shredder)
+			}
+		})
+	}
+
+	// File letters make a game Chess960 without being asked
+	g, err := NewGameFromFEN("1r2k1r1/8/8/8/8/8/8/1R2K1R1 w GBgb - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Variant() != Chess960 {
+		t.Errorf("Variant() = %s, want Chess960", g.Variant().Name())
+	}
+}
+
+func TestChess960PGNRoundTrip(t *testing.T) {
+	g := NewGame(WithVariant(Chess960), WithStartPosition(0))
+	g.TimeControl = nil
+	// Clear the way for white to castle queenside, then castle
+	playMoves(t, g, "d1 c3", "d8 c6", "e1 d3", "e8 d6", "b2 b3", "b7 b6", "c1 b2", "c8 b7")
+	move, err := g.ParseMove("O-O-O")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.PlayMove(move); err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	if err := g.WritePGN(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	text := sb.String()
+	for _, tag := range []string{`[Variant "Chess960"]`, `[FEN "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"]`, "O-O-O"} {
+		if !strings.Contains(text, tag) {
+			t.Errorf("PGN does not contain %s:\n%s", tag, text)
+		}
+	}
+
+	pg, err := pgn.NewParser(strings.NewReader(text)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewGameFromPGN(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Variant() != Chess960 || loaded.FEN() != g.FEN() {
+		t.Errorf("round trip gave %s %q, want Chess960 %q", loaded.Variant().Name(), loaded.FEN(), g.FEN())
+	}
+
+	filename := filepath.Join(t.TempDir(), "game.json")
+	if err := g.SaveGame(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded = NewGame()
+	if err := loaded.LoadGame(filename); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Variant() != Chess960 || loaded.FEN() != g.FEN() {
+		t.Errorf("JSON round trip gave %s %q, want Chess960 %q", loaded.Variant().Name(), loaded.FEN(), g.FEN())
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	tests := []struct {
+		name string
+		want Variant
+	}{
+		{"", Standard},
+		{"Standard", Standard},
+		{"Chess960", Chess960},
+		{"fischerandom", Chess960},
+		{"King of the Hill", KingOfTheHill},
+		{"kingofthehill", KingOfTheHill},
+		{"Three-check", ThreeCheck},
+		{"3check", ThreeCheck},
+		{"horde", Horde},
+		{"Racing Kings", RacingKings},
+		{"racingkings", RacingKings},
+		{"Fog of War", FogOfWar},
+		{"dark chess", FogOfWar},
+		{"Bughouse", Bughouse},
+		{"tandem", Bughouse},
+	}
+	for _, tt := range tests {
+		if got, err := ParseVariant(tt.name); err != nil || got != tt.want {
+			t.Errorf("ParseVariant(%q) = %v, %v, want %s", tt.name, got, err, tt.want.Name())
+		}
+	}
+	if _, err := ParseVariant("shogi"); err == nil {
+		t.Error("ParseVariant accepted an unknown variant")
+	}
+
+	for _, v := range Variants() {
+		if got, err := ParseVariant(v.Name()); err != nil || got != v {
+			t.Errorf("ParseVariant(%q) does not find the variant", v.Name())
+		}
+	}
+}
+
+// pawnsOnly is a variant built the way one outside the package would be,
+// by embedding Standard and overriding the moves
+type pawnsOnly struct{ Variant }
+
+func (pawnsOnly) LegalMoves(p *bitboard.Position) []bitboard.Move {
+	var moves []bitboard.Move
+	for _, m := range p.LegalMoves() {
+		if p.Piece(m.From).Type == board.Pawn {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+func TestEmbeddedVariant(t *testing.T) {
+	g := NewGame(WithVariant(pawnsOnly{Variant: Standard}))
+	if got := len(g.LegalMoves()); got != 16 {
+		t.Errorf("LegalMoves() has %d moves, want the 16 pawn moves", got)
+	}
+	if err := g.MakeMove(mustPos(t, "g1"), mustPos(t, "f3")); err == nil {
+		t.Error("MakeMove(g1, f3) succeeded with only pawn moves allowed")
+	}
+	playMoves(t, g, "e2 e4")
+	if g.Variant().Name() != "Standard" {
+		t.Errorf("Name() = %q, want the embedded Standard name", g.Variant().Name())
+	}
+}
+
+func TestChess960KeepsRookSquares(t *testing.T) {
+	// Moving the inner rook must not cost the right of the outer one
+	g, err := NewGameFromFEN("r2k1rr1/8/8/8/8/8/8/R2K1RR1 w GAga - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "f1 f2")
+	p := g.Position()
+	if p.Castling()&bitboard.WhiteKingSide == 0 {
+		t.Errorf("moving the f1 rook lost castling with the g1 rook: %s", g.ShredderFEN())
+	}
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want Result
+	}{
+		{"white reaches e4", "4k3/8/8/8/8/4K3/8/8 w - - 0 1", "e3e4", win(board.White, ReasonKingOfTheHill)},
+		{"black reaches d5", "8/8/3k4/8/8/8/8/4K3 b - - 0 1", "d6d5", win(board.Black, ReasonKingOfTheHill)},
+		{"king next to the hill", "4k3/8/8/8/8/4K3/8/8 w - - 0 1", "e3f4", Result{}},
+		{"checkmate still wins", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", win(board.White, ReasonCheckmate)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(KingOfTheHill))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			// Lone kings can still win, so the game goes on
+			if g.IsOver() {
+				t.Fatalf("game over before the move: %s", g.Result().Description())
+			}
+			move, err := g.ParseMove(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := g.PlayMove(move); err != nil {
+				t.Fatal(err)
+			}
+			if got := g.Result(); got != tt.want {
+				t.Errorf("Result() = %q, want %q", got.Description(), tt.want.Description())
+			}
+		})
+	}
+}
+
+func TestKingOfTheHillTimeout(t *testing.T) {
+	g, err := NewGameFromFEN("4k3/8/8/8/8/8/8/Q3K3 b - - 0 1", WithVariant(KingOfTheHill))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.timeOut()
+	if want := win(board.White, ReasonTimeout); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+
+	// A lone king can still reach the center
+	g, err = NewGameFromFEN("4k3/8/8/8/8/8/8/Q3K3 w - - 0 1", WithVariant(KingOfTheHill))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.timeOut()
+	if want := win(board.Black, ReasonTimeout); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+}
+
+func TestThreeCheck(t *testing.T) {
+	g := NewGame(WithVariant(ThreeCheck))
+	if want := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3+3 0 1"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+
+	// White has given two checks, and the bishop gives the third
+	g, err := NewGameFromFEN("rnbqkbnr/ppp2ppp/8/3pp3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 1+3 0 3", WithVariant(ThreeCheck))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	if got := g.Checks(board.White); got != 2 {
+		t.Errorf("Checks(White) = %d, want 2", got)
+	}
+	playMoves(t, g, "f1 b5")
+	if want := win(board.White, ReasonThreeChecks); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+	if want := "rnbqkbnr/ppp2ppp/8/1B1pp3/4P3/8/PPPP1PPP/RNBQK1NR b KQkq - 0+3 1 3"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if g.IsOver() || g.Checks(board.White) != 2 {
+		t.Errorf("Undo left the game over or %d checks, want 2", g.Checks(board.White))
+	}
+}
+
+func TestThreeCheckFEN(t *testing.T) {
+	tests := []struct {
+		fen          string
+		white, black int
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 2+1 0 1", 1, 2},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +2+1", 2, 1},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0, 0},
+	}
+
+	for _, tt := range tests {
+		g, err := NewGameFromFEN(tt.fen, WithVariant(ThreeCheck))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Checks(board.White) != tt.white || g.Checks(board.Black) != tt.black {
+			t.Errorf("%s: checks = %d+%d, want %d+%d", tt.fen, g.Checks(board.White), g.Checks(board.Black), tt.white, tt.black)
+		}
+		again, err := NewGameFromFEN(g.FEN(), WithVariant(ThreeCheck))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.FEN() != g.FEN() || again.Hash() != g.Hash() {
+			t.Errorf("%s: round trip gave %q", tt.fen, again.FEN())
+		}
+	}
+
+	// Positions that differ only in the checks given are not repetitions
+	a, _ := NewGameFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3+3 0 1", WithVariant(ThreeCheck))
+	b, _ := NewGameFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 2+3 0 1", WithVariant(ThreeCheck))
+	if a.Hash() == b.Hash() {
+		t.Error("positions with different checks given have the same hash")
+	}
+
+	if _, err := NewGameFromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 +5+0", WithVariant(ThreeCheck)); err == nil {
+		t.Error("NewGameFromFEN accepted five checks")
+	}
+}
+
+func TestThreeCheckInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		fen  string
+		over bool
+	}{
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/4KN2 w - - 0 1", false},
+	}
+	for _, tt := range tests {
+		g, err := NewGameFromFEN(tt.fen, WithVariant(ThreeCheck))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.IsOver() != tt.over {
+			t.Errorf("%s: IsOver() = %v, want %v", tt.fen, g.IsOver(), tt.over)
+		}
+	}
+}
+
+func TestThreeCheckTimeout(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want Result
+	}{
+		{"4k3/8/8/8/8/8/8/1K4n1 w - - 0 1", win(board.Black, ReasonTimeout)},
+		{"4k3/8/8/8/8/8/8/1K4N1 w - - 0 1", draw(ReasonTimeoutVsInsufficientMaterial)},
+	}
+	for _, tt := range tests {
+		g, err := NewGameFromFEN(tt.fen, WithVariant(ThreeCheck))
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.TimeControl.WhiteTimeLeft = 0
+		if err := g.MakeMove(mustPos(t, "b1"), mustPos(t, "b2")); err == nil {
+			t.Fatalf("%s: MakeMove after the flag fell succeeded", tt.fen)
+		}
+		if g.Result() != tt.want {
+			t.Errorf("%s: Result() = %v, want %v", tt.fen, g.Result(), tt.want)
+		}
+	}
+}
+
+func TestVariantPGNRoundTrip(t *testing.T) {
+	openings := []struct {
+		variant Variant
+		moves   []string
+	}{
+		{KingOfTheHill, []string{"e2 e4", "f7 f6", "d1 h5"}},
+		{ThreeCheck, []string{"e2 e4", "f7 f6", "d1 h5"}},
+		{Atomic, []string{"e2 e4", "f7 f6", "d1 h5"}},
+		{Antichess, []string{"e2 e4", "f7 f6", "d1 h5"}},
+		{Horde, []string{"b5 b6", "a7 b6", "c5 b6"}},
+		{RacingKings, []string{"h2 h3", "a2 a3", "e2 d4"}},
+		{Kriegspiel, []string{"e2 e4", "f7 f6", "d1 h5"}},
+		{FogOfWar, []string{"e2 e4", "f7 f6", "d1 h5"}},
+		{Bughouse, []string{"e2 e4", "d7 d5", "e4 d5"}},
+	}
+	for _, opening := range openings {
+		variant := opening.variant
+		t.Run(variant.Name(), func(t *testing.T) {
+			g := NewGame(WithVariant(variant))
+			g.TimeControl = nil
+			playMoves(t, g, opening.moves...)
+
+			var sb strings.Builder
+			if err := g.WritePGN(&sb, nil); err != nil {
+				t.Fatal(err)
+			}
+			text := sb.String()
+			if tag := `[Variant "` + variant.Name() + `"]`; !strings.Contains(text, tag) {
+				t.Errorf("PGN does not contain %s:\n%s", tag, text)
+			}
+			if strings.Contains(text, "[FEN") {
+				t.Errorf("PGN of a game from the starting position has a FEN tag:\n%s", text)
+			}
+
+			pg, err := pgn.NewParser(strings.NewReader(text)).Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := NewGameFromPGN(pg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Variant() != variant || loaded.FEN() != g.FEN() {
+				t.Errorf("round trip gave %s %q, want %q", loaded.Variant().Name(), loaded.FEN(), g.FEN())
+			}
+		})
+	}
+}
+
+func TestCrazyhouseDrops(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want int
+	}{
+		// Three king moves, and a knight drop on each of the 62 empty squares
+		{"k7/8/8/8/8/8/8/K7[N] w - - 0 1", 65},
+		// Pawns cannot be dropped on the first or last rank
+		{"k7/8/8/8/8/8/8/K7[P] w - - 0 1", 51},
+		// Black's pieces cannot be dropped by White
+		{"k7/8/8/8/8/8/8/K7[n] w - - 0 1", 3},
+		// In check, a drop must block it
+		{"k7/8/8/8/8/8/8/K6r[N] w - - 0 1", 8},
+	}
+
+	for _, tt := range tests {
+		g, err := NewGameFromFEN(tt.fen, WithVariant(Crazyhouse))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(g.LegalMoves()); got != tt.want {
+			t.Errorf("%s: %d legal moves, want %d", tt.fen, got, tt.want)
+		}
+	}
+}
+
+func TestCrazyhousePockets(t *testing.T) {
+	g := NewGame(WithVariant(Crazyhouse))
+	g.TimeControl = nil
+	playMoves(t, g, "e4", "d5", "exd5", "Qxd5")
+	if want := "rnb1kbnr/ppp1pppp/8/3q4/8/8/PPPP1PPP/RNBQKBNR[Pp] w KQkq - 0 3"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+	if got := g.Pocket(board.White)[board.Pawn]; got != 1 {
+		t.Errorf("White has %d pawns in hand, want 1", got)
+	}
+
+	move, err := g.ParseMove("P@e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if move.Drop != board.Pawn || move.To != pos("e4") {
+		t.Errorf("ParseMove(P@e4) = %+v", move)
+	}
+	if san, _ := g.SAN(move); san != "P@e4" {
+		t.Errorf("SAN() = %q, want P@e4", san)
+	}
+	if err := g.PlayMove(move); err != nil {
+		t.Fatal(err)
+	}
+	if !g.Pocket(board.White).IsEmpty() {
+		t.Errorf("White pocket after the drop = %v, want empty", g.Pocket(board.White))
+	}
+	if _, err := g.ParseMove("N@f6"); err == nil {
+		t.Error("ParseMove accepted a drop of a piece not in hand")
+	}
+
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Pocket(board.White)[board.Pawn]; got != 1 {
+		t.Errorf("Undo left %d pawns in hand, want 1", got)
+	}
+}
+
+func TestCrazyhousePromotedPieces(t *testing.T) {
+	g, err := NewGameFromFEN("k2r4/2P5/8/8/8/8/8/4K3[] w - - 0 1", WithVariant(Crazyhouse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "c8=Q+")
+	if want := "k1Q~r4/8/8/8/8/8/8/4K3[] b - - 0 1"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+
+	// The captured queen goes to the pocket as a pawn
+	playMoves(t, g, "Rxc8")
+	if want := (board.Pocket{board.Pawn: 1}); g.Pocket(board.Black) != want {
+		t.Errorf("Black pocket = %v, want a pawn", g.Pocket(board.Black))
+	}
+
+	// Both ways of writing the pockets, with the promoted queen marked
+	for _, fen := range []string{
+		"k1Q~r4/8/8/8/8/8/8/4K3[Nq] b - - 0 1",
+		"k1Q~r4/8/8/8/8/8/8/4K3/Nq b - - 0 1",
+	} {
+		g, err := NewGameFromFEN(fen, WithVariant(Crazyhouse))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "k1Q~r4/8/8/8/8/8/8/4K3[Nq] b - - 0 1"; g.FEN() != want {
+			t.Errorf("%s: FEN() = %q, want %q", fen, g.FEN(), want)
+		}
+	}
+
+	for _, fen := range []string{
+		"k7/8/8/8/8/8/8/K7[K] w - - 0 1",
+		"k7/8/8/8/8/8/8/K7[N w - - 0 1",
+		"k7/8/8/8/8/8/8/K7[X] w - - 0 1",
+	} {
+		if _, err := NewGameFromFEN(fen, WithVariant(Crazyhouse)); err == nil {
+			t.Errorf("NewGameFromFEN(%q) expected an error", fen)
+		}
+	}
+}
+
+func TestCrazyhouseInsufficientMaterial(t *testing.T) {
+	g, err := NewGameFromFEN("k7/8/8/8/8/8/8/K7[n] w - - 0 1", WithVariant(Crazyhouse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.IsOver() {
+		t.Errorf("game with a piece in hand ended: %s", g.Result().Description())
+	}
+}
+
+func TestCrazyhouseRoundTrip(t *testing.T) {
+	g := NewGame(WithVariant(Crazyhouse))
+	g.TimeControl = nil
+	playMoves(t, g, "e4", "d5", "exd5", "Qxd5", "Nc3", "Qa5", "P@d5", "P@e6")
+
+	var sb strings.Builder
+	if err := g.WritePGN(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "4. P@d5 P@e6") {
+		t.Errorf("PGN does not contain the drops:\n%s", sb.String())
+	}
+	pg, err := pgn.NewParser(strings.NewReader(sb.String())).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewGameFromPGN(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Variant() != Crazyhouse || loaded.FEN() != g.FEN() {
+		t.Errorf("PGN round trip gave %s %q, want %q", loaded.Variant().Name(), loaded.FEN(), g.FEN())
+	}
+
+	filename := filepath.Join(t.TempDir(), "game.json")
+	if err := g.SaveGame(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded = NewGame()
+	if err := loaded.LoadGame(filename); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Variant() != Crazyhouse || loaded.FEN() != g.FEN() {
+		t.Errorf("JSON round trip gave %s %q, want %q", loaded.Variant().Name(), loaded.FEN(), g.FEN())
+	}
+}
+
+func TestAtomic(t *testing.T) {
+	g := NewGame(WithVariant(Atomic))
+	g.TimeControl = nil
+	// Nxf7 blows up the king on e8 along with the pieces around it
+	playMoves(t, g, "g1 f3", "a7 a6", "f3 g5", "a6 a5")
+	move, err := g.ParseMove("Nxf7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.PlayMove(move); err != nil {
+		t.Fatal(err)
+	}
+	if want := win(board.White, ReasonExplosion); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+	if want := "rnbq3r/1pppp1pp/8/p7/8/8/PPPPPPPP/RNBQKB1R b KQ - 0 3"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+}
+
+func TestAtomicKingsCannotCapture(t *testing.T) {
+	// Taking the queen would blow up the white king, so the rook mates
+	g, err := NewGameFromFEN("k7/8/8/8/8/8/1q6/K6r w - - 0 1", WithVariant(Atomic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := win(board.Black, ReasonCheckmate); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+
+	// With the kings touching neither side is in check
+	g, err = NewGameFromFEN("8/8/8/3k4/3K4/8/8/r7 w - - 0 1", WithVariant(Atomic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.State != InProgress {
+		t.Errorf("State = %v with the kings touching, want InProgress", g.State)
+	}
+}
+
+func TestAtomicInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		fen  string
+		over bool
+	}{
+		{"k7/8/8/8/8/8/8/K7 w - - 0 1", true},
+		{"k7/8/8/8/8/8/8/KB6 w - - 0 1", true},
+		// Bishops on squares of the same color can blow each other up
+		{"k7/8/8/8/8/8/8/KB3b2 w - - 0 1", false},
+	}
+	for _, tt := range tests {
+		g, err := NewGameFromFEN(tt.fen, WithVariant(Atomic))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.IsOver() != tt.over {
+			t.Errorf("%s: IsOver() = %v, want %v", tt.fen, g.IsOver(), tt.over)
+		}
+	}
+
+	// A knight cannot win against a bare king, even on time
+	g, err := NewGameFromFEN("k7/8/8/8/8/8/8/KN6 b - - 0 1", WithVariant(Atomic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.timeOut()
+	if want := draw(ReasonTimeoutVsInsufficientMaterial); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+}
+
+func TestAntichess(t *testing.T) {
+	g := NewGame(WithVariant(Antichess))
+	g.TimeControl = nil
+	if want := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+
+	// After 1. e3 b5 White must take the pawn
+	playMoves(t, g, "e3", "b5")
+	moves := g.LegalMoves()
+	if len(moves) != 1 || moves[0].To != pos("b5") {
+		t.Errorf("LegalMoves() = %v, want only Bxb5", moves)
+	}
+	if _, err := g.ParseMove("d4"); err == nil {
+		t.Error("ParseMove accepted a move that does not capture")
+	}
+
+	// The king can be captured, and is never in check
+	g, err := NewGameFromFEN("8/8/8/8/8/8/1k6/K1R5 b - - 0 1", WithVariant(Antichess))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	if g.State != InProgress {
+		t.Errorf("State = %v, want InProgress", g.State)
+	}
+	playMoves(t, g, "Kxa1")
+	if g.IsOver() {
+		t.Errorf("game over after Kxa1: %s", g.Result().Description())
+	}
+}
+
+func TestAntichessResult(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want Result
+	}{
+		{"losing the last piece", "8/8/8/8/8/8/1p6/R7 b - - 0 1", "bxa1=Q", win(board.White, ReasonNoPieces)},
+		{"stalemated", "8/8/8/8/8/p7/P7/8 w - - 0 1", "", win(board.White, ReasonStalemate)},
+		{"kings alone play on", "8/8/8/8/8/8/1k6/K7 w - - 0 1", "", Result{}},
+		{"bishops that cannot meet", "8/8/8/8/8/1b6/8/B7 w - - 0 1", "", draw(ReasonInsufficientMaterial)},
+		{"bishops that can meet", "8/8/8/8/8/8/1b6/B7 w - - 0 1", "", Result{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(Antichess))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			if tt.move != "" {
+				playMoves(t, g, tt.move)
+			}
+			if got := g.Result(); got != tt.want {
+				t.Errorf("Result() = %q, want %q", got.Description(), tt.want.Description())
+			}
+		})
+	}
+}
+
+func TestAntichessPromotionToKing(t *testing.T) {
+	g, err := NewGameFromFEN("8/4P3/8/8/8/8/8/k7 w - - 0 1", WithVariant(Antichess))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	for _, text := range []string{"e8=K", "e7e8k"} {
+		move, err := g.ParseMove(text)
+		if err != nil {
+			t.Fatalf("ParseMove(%q) error = %v", text, err)
+		}
+		if move.PromotionType != board.King {
+			t.Errorf("ParseMove(%q) promotes to %v, want a king", text, move.PromotionType)
+		}
+	}
+	playMoves(t, g, "e8=K")
+	if want := "4K3/8/8/8/8/8/8/k7 b - - 0 1"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+}
+
+func TestHorde(t *testing.T) {
+	g := NewGame(WithVariant(Horde))
+	if want := "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+
+	// A pawn on the first rank may move two squares but cannot be taken
+	// en passant afterwards
+	g, err := NewGameFromFEN("4k3/8/8/8/8/8/1p6/P7 w - - 0 1", WithVariant(Horde))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	playMoves(t, g, "a3")
+	if want := "4k3/8/8/8/8/P7/1p6/8 b - - 0 1"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/8/P7 w - - 0 1",
+	} {
+		if _, err := NewGameFromFEN(fen); err == nil {
+			t.Errorf("standard chess accepted %q", fen)
+		}
+	}
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/8/P3K3 w - - 0 1",
+		"P3k3/8/8/8/8/8/8/8 w - - 0 1",
+	} {
+		if _, err := NewGameFromFEN(fen, WithVariant(Horde)); err == nil {
+			t.Errorf("Horde accepted %q", fen)
+		}
+	}
+}
+
+func TestHordeResult(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want Result
+	}{
+		{"horde destroyed", "r3k3/8/8/8/8/8/P7/8 b - - 0 1", "Rxa2", win(board.Black, ReasonHordeDestroyed)},
+		{"checkmate", "7k/5P1p/5PP1/8/8/8/8/8 w - - 0 1", "g7", win(board.White, ReasonCheckmate)},
+		{"lone pieces play on", "4k3/8/8/8/8/8/8/N7 w - - 0 1", "", Result{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(Horde))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			if tt.move != "" {
+				playMoves(t, g, tt.move)
+			}
+			if got := g.Result(); got != tt.want {
+				t.Errorf("Result() = %q, want %q", got.Description(), tt.want.Description())
+			}
+		})
+	}
+
+	// Black can still take the horde when White runs out of time
+	g, err := NewGameFromFEN("4k3/8/8/8/8/8/8/N7 w - - 0 1", WithVariant(Horde))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.timeOut()
+	if want := win(board.Black, ReasonTimeout); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+}
+
+func TestRacingKings(t *testing.T) {
+	g := NewGame(WithVariant(RacingKings))
+	if want := "8/8/8/8/8/8/krbnNBRK/qrbnNBRQ w - - 0 1"; g.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", g.FEN(), want)
+	}
+
+	// Moves that give check are not allowed
+	g, err := NewGameFromFEN("8/k7/8/8/8/8/8/1R5K w - - 0 1", WithVariant(RacingKings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"Ra1", "Rb7"} {
+		if _, err := g.ParseMove(text); err == nil {
+			t.Errorf("ParseMove(%q) accepted a move that gives check", text)
+		}
+	}
+	if _, err := g.ParseMove("Rb6"); err != nil {
+		t.Errorf("ParseMove(%q) error = %v", "Rb6", err)
+	}
+
+	if _, err := NewGameFromFEN("8/8/8/8/8/8/k5rK/8 w - - 0 1", WithVariant(RacingKings)); err == nil {
+		t.Error("Racing Kings accepted a position with the side to move in check")
+	}
+}
+
+func TestRacingKingsResult(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		want  Result
+	}{
+		{"black reaches the goal", "8/1k6/8/8/8/8/8/7K b - - 0 1", []string{"Kb8"}, win(board.Black, ReasonGoal)},
+		{"white reaches the goal", "8/6K1/8/8/8/8/k7/8 w - - 0 1", []string{"Kg8"}, win(board.White, ReasonGoal)},
+		{"black can follow", "8/k5K1/8/8/8/8/8/8 w - - 0 1", []string{"Kg8"}, Result{}},
+		{"black follows", "8/k5K1/8/8/8/8/8/8 w - - 0 1", []string{"Kg8", "Kb8"}, draw(ReasonGoal)},
+		{"black does not follow", "8/k5K1/8/8/8/8/8/8 w - - 0 1", []string{"Kg8", "Kb6"}, win(board.White, ReasonGoal)},
+		{"kings alone play on", "8/8/8/8/8/8/k6K/8 w - - 0 1", nil, Result{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(RacingKings))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			playMoves(t, g, tt.moves...)
+			if got := g.Result(); got != tt.want {
+				t.Errorf("Result() = %q, want %q", got.Description(), tt.want.Description())
+			}
+		})
+	}
+}
+
+func TestKriegspielReferee(t *testing.T) {
+	g := NewGame(WithVariant(Kriegspiel))
+	g.TimeControl = nil
+	r := NewReferee(g)
+
+	attempt := func(text string, want ...Announcement) {
+		t.Helper()
+		got, err := r.Attempt(text)
+		if err != nil {
+			t.Fatalf("Attempt(%q) error = %v", text, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Attempt(%q) = %v, want %v", text, got, want)
+		}
+	}
+
+	attempt("e5", Announcement{Kind: Illegal, Color: board.White})
+	if g.FEN() != StartFEN {
+		t.Errorf("illegal move changed the position to %q", g.FEN())
+	}
+	if _, err := r.Attempt("Zz9"); err == nil {
+		t.Error("Attempt accepted text that is not a move")
+	}
+
+	attempt("e4")
+	attempt("d5", Announcement{Kind: PawnTries, Color: board.White, Count: 1})
+	attempt("exd5", Announcement{Kind: PawnCaptured, Color: board.Black, Square: mustPos(t, "d5")})
+	attempt("Qxd5", Announcement{Kind: PawnCaptured, Color: board.White, Square: mustPos(t, "d5")})
+	attempt("Nc3")
+	attempt("Qe5", Announcement{Kind: CheckOnFile, Color: board.White})
+
+	if got := len(r.Log()); got != 5 {
+		t.Errorf("Log() has %d announcements, want 5", got)
+	}
+}
+
+func TestKriegspielAnnouncements(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want string
+	}{
+		{"rank", "4k3/8/8/8/8/8/8/r3K3 w - - 0 1", "", "[Check on the rank]"},
+		{"file", "4k3/8/8/8/4r3/8/8/4K3 w - - 0 1", "", "[Check on the file]"},
+		{"long diagonal", "4k3/8/8/8/1b6/8/8/4K3 w - - 0 1", "", "[Check on the long diagonal]"},
+		{"short diagonal", "4k3/8/8/8/7b/8/8/4K3 w - - 0 1", "", "[Check on the short diagonal]"},
+		{"knight", "4k3/8/8/8/8/5n2/8/4K3 w - - 0 1", "", "[Knight check]"},
+		{"double check", "4k3/8/8/8/1b6/8/8/r3K3 w - - 0 1", "", "[Check on the long diagonal Check on the rank]"},
+		{"promoting capture is one try", "1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "", "[1 pawn try]"},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6", "[Pawn captured on d5]"},
+		{"checkmate", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "Qh4", "[Check on the short diagonal]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen, WithVariant(Kriegspiel))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			r := NewReferee(g)
+			got := r.Log()
+			if tt.move != "" {
+				if got, err = r.Attempt(tt.move); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("announcements = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFogOfWar(t *testing.T) {
+	g := NewGame(WithVariant(FogOfWar))
+	g.TimeControl = nil
+	// Qh4 is not mate, as there is no check, but White fails to see the
+	// queen and loses the king
+	playMoves(t, g, "f3", "e5", "g4", "Qh4")
+	if g.State != InProgress {
+		t.Errorf("State = %v after Qh4, want InProgress", g.State)
+	}
+	if got := g.Visible(board.White); got.Has(bitboard.SquareOf(mustPos(t, "h4"))) {
+		t.Error("White sees the queen on h4")
+	}
+	if got := g.Visible(board.Black); !got.Has(bitboard.SquareOf(mustPos(t, "e1"))) {
+		t.Error("Black cannot see the king on e1")
+	}
+	playMoves(t, g, "a3", "Qxe1")
+	if want := win(board.Black, ReasonKingCaptured); g.Result() != want {
+		t.Errorf("Result() = %q, want %q", g.Result().Description(), want.Description())
+	}
+
+	// A position where the side not to move is attacked is fine, as the
+	// king may stand in an attack
+	if _, err := NewGameFromFEN("4k3/8/8/8/8/8/8/4RK2 w - - 0 1", WithVariant(FogOfWar)); err != nil {
+		t.Errorf("NewGameFromFEN() error = %v", err)
+	}
+	if g := NewGame(); g.Visible(board.White) != ^bitboard.Bitboard(0) {
+		t.Error("part of the board is hidden in standard chess")
+	}
+}
+
+func TestBughouse(t *testing.T) {
+	m := NewMatch()
+	a, b := m.Board(0), m.Board(1)
+	a.TimeControl, b.TimeControl = nil, nil
+
+	// The pawn White takes on board 0 goes to Black, White's partner, on
+	// board 1
+	playMoves(t, a, "e4", "d5", "exd5")
+	if got := a.Pocket(board.White); !got.IsEmpty() {
+		t.Errorf("the capturer's pocket = %v, want empty", got)
+	}
+	if got := b.Pocket(board.Black); got != (board.Pocket{board.Pawn: 1}) {
+		t.Errorf("the partner's pocket = %v, want a pawn", got)
+	}
+	playMoves(t, b, "Nf3", "P@e4")
+	if got := b.Pocket(board.Black); !got.IsEmpty() {
+		t.Errorf("pocket after the drop = %v, want empty", got)
+	}
+
+	if err := a.Undo(); err == nil {
+		t.Error("Undo() succeeded in a match")
+	}
+	if m.IsOver() {
+		t.Fatal("match over before either game ended")
+	}
+
+	// The match ends with the first game to end
+	if err := a.Resign(board.Black); err != nil {
+		t.Fatal(err)
+	}
+	if i, result := m.Result(); i != 0 || result != win(board.White, ReasonResignation) {
+		t.Errorf("Result() = %d, %q, want board 0 won by White", i, result.Description())
+	}
+	if got := m.Team(0, board.White); got != m.Team(1, board.Black) {
+		t.Errorf("White on board 0 is in team %d but their partner in team %d", got, m.Team(1, board.Black))
+	}
+	move, err := b.ParseMove("d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.PlayMove(1, move); err == nil {
+		t.Error("PlayMove() succeeded after the match ended")
+	}
+}
+
+func TestBughousePromotedCapture(t *testing.T) {
+	a, err := NewGameFromFEN("k7/8/8/8/8/8/7q~/K6R w - - 0 1", WithVariant(Bughouse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewGame(WithVariant(Bughouse))
+	a.partner, b.partner = b, a
+
+	// A promoted queen goes back to being a pawn
+	playMoves(t, a, "Rxh2")
+	if got := b.Pocket(board.Black); got != (board.Pocket{board.Pawn: 1}) {
+		t.Errorf("the partner's pocket = %v, want a pawn", got)
+	}
+}
+
+func TestBughouseTimeout(t *testing.T) {
+	m := NewMatch()
+	a, b := m.Board(0), m.Board(1)
+	a.TimeControl = nil
+	playMoves(t, b, "e4")
+
+	// Black's clock on board 1 runs out while nobody moves there
+	b.TimeControl.BlackTimeLeft = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	if i, result := m.Result(); i != 1 || result != win(board.White, ReasonTimeout) {
+		t.Errorf("Result() = %d, %q, want board 1 won by White on time", i, result.Description())
+	}
+}
@@ -0,0 +1,35 @@
+package game
+
+import "github.com/user/chess/pkg/bitboard"
+
+// Hash returns the Zobrist hash of the current position. Positions that
+// are the same for the repetition rules have the same hash.
+func (g *Game) Hash() uint64 {
+	return g.pos.Hash()
+}
+
+// resetHash starts a new repetition history with the current position
+func (g *Game) resetHash() {
+	g.positionHashes = []uint64{g.pos.Hash()}
+}
+
+// RepetitionCount returns how many times the current position has occurred
+// in the game, including now
+func (g *Game) RepetitionCount() int {
+	count := 0
+	hash := g.pos.Hash()
+	// Captures and pawn moves cannot be undone, so only positions since the
+	// last of them can repeat. With drops they can: a captured piece, pawns
+	// included, may be dropped back where it stood.
+	last := len(g.positionHashes) - 1
+	first := last - g.pos.HalfMoveClock()
+	if g.pos.Rules().Has(bitboard.Crazyhouse) {
+		first = 0
+	}
+	for i := last; i >= 0 && i >= first; i-- {
+		if g.positionHashes[i] == hash {
+			count++
+		}
+	}
+	return count
+}
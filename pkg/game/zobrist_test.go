@@ -0,0 +1,169 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHashMatchesRecomputed(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 50; i++ {
+		g := NewGame()
+		g.TimeControl = nil
+
+		for ply := 0; ply < 150; ply++ {
+			fresh, err := NewGameFromFEN(g.FEN())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Hash() != fresh.Hash() {
+				t.Fatalf("incremental hash differs after %v: %s", g.moveHistory, g.FEN())
+			}
+			moves := g.LegalMoves()
+			if len(moves) == 0 || (g.State != InProgress && g.State != Check) {
+				break
+			}
+			if err := g.PlayMove(moves[rng.Intn(len(moves))]); err != nil {
+				t.Fatal(err)
+			}
+			if rng.Intn(10) == 0 {
+				if err := g.Undo(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+func TestHashIdentifiesPositions(t *testing.T) {
+	hash := func(fen string) uint64 {
+		t.Helper()
+		g, err := NewGameFromFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g.Hash()
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"move clocks are ignored", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", "4k3/8/8/8/8/8/8/4K3 w - - 12 40", true},
+		{"side to move", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", "4k3/8/8/8/8/8/8/4K3 b - - 0 1", false},
+		{"castling rights", "r3k3/8/8/8/8/8/8/4K3 b q - 0 1", "r3k3/8/8/8/8/8/8/4K3 b - - 0 1", false},
+		{"en passant possible", "4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1", "4k3/8/8/8/3pP3/8/8/4K3 b - - 0 1", false},
+		{"en passant impossible", "4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1", "4k3/8/8/8/4P3/8/8/4K3 b - - 0 1", true},
+	}
+	for _, tt := range tests {
+		if got := hash(tt.a) == hash(tt.b); got != tt.same {
+			t.Errorf("%s: same hash = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+
+	// Reaching the start position again by a transposition gives its hash
+	g := NewGame()
+	g.TimeControl = nil
+	start := g.Hash()
+	playMoves(t, g, "g1 f3", "g8 f6", "f3 g1", "f6 g8")
+	if g.Hash() != start {
+		t.Error("hash of the start position changed after knight moves")
+	}
+}
+
+func TestRepetition(t *testing.T) {
+	g := NewGame()
+	g.TimeControl = nil
+	shuffle := []string{"g1 f3", "g8 f6", "f3 g1", "f6 g8"}
+
+	playMoves(t, g, shuffle...)
+	if got := g.RepetitionCount(); got != 2 {
+		t.Errorf("RepetitionCount() = %d, want 2", got)
+	}
+	if g.CanClaimDraw() {
+		t.Error("CanClaimDraw() after the second occurrence")
+	}
+
+	playMoves(t, g, shuffle...)
+	if got := g.RepetitionCount(); got != 3 {
+		t.Errorf("RepetitionCount() = %d, want 3", got)
+	}
+	if !g.CanClaimDraw() || g.State != InProgress {
+		t.Errorf("threefold repetition: CanClaimDraw() = %v, State = %v", g.CanClaimDraw(), g.State)
+	}
+
+	playMoves(t, g, shuffle...)
+	playMoves(t, g, shuffle[:3]...)
+	if g.State != InProgress {
+		t.Fatalf("State = %v before the fifth occurrence", g.State)
+	}
+	playMoves(t, g, shuffle[3])
+	if g.State != Draw || g.GetGameStatus() != "Draw by fivefold repetition" {
+		t.Errorf("fivefold repetition: State = %v, status %q", g.State, g.GetGameStatus())
+	}
+
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if g.State != InProgress || g.RepetitionCount() != 4 {
+		t.Errorf("after undo: State = %v, RepetitionCount() = %d", g.State, g.RepetitionCount())
+	}
+}
+
+func TestRepetitionAcrossDrops(t *testing.T) {
+	g, err := NewGameFromFEN("3r4/2k5/8/8/8/8/8/3R3K w - - 0 1", WithVariant(Crazyhouse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TimeControl = nil
+	// The rooks are traded and dropped back, and the kings walk round
+	cycle := []string{"Rxd8", "Kxd8", "R@d1+", "Kc7", "Kg1", "R@d8", "Kg2", "Kb7", "Kh1", "Kc7"}
+
+	playMoves(t, g, cycle...)
+	if got := g.RepetitionCount(); got != 2 {
+		t.Errorf("RepetitionCount() = %d, want 2", got)
+	}
+	playMoves(t, g, cycle...)
+	if got := g.RepetitionCount(); got != 3 {
+		t.Errorf("RepetitionCount() = %d, want 3", got)
+	}
+	if !g.CanClaimDraw() {
+		t.Error("CanClaimDraw() = false after a threefold repetition across captures")
+	}
+}
+
+func TestMoveRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		fen       string
+		move      string
+		wantState GameState
+		wantClaim bool
+	}{
+		{"49 moves", "4k3/8/8/8/8/8/8/R3K3 w - - 97 80", "a1 a2", InProgress, false},
+		{"50 moves can be claimed", "4k3/8/8/8/8/8/8/R3K3 w - - 99 80", "a1 a2", InProgress, true},
+		{"capture resets the count", "4k3/8/8/8/8/8/r7/R3K3 w - - 99 80", "a1 a2", InProgress, false},
+		{"75 moves end the game", "4k3/8/8/8/8/8/8/R3K3 w - - 149 80", "a1 a2", Draw, false},
+		{"mate on the 75th move", "4k3/R7/8/8/8/8/8/4K2R w - - 149 80", "h1 h8", Checkmate, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.TimeControl = nil
+			playMoves(t, g, tt.move)
+
+			if g.State != tt.wantState {
+				t.Errorf("State = %v, want %v", g.State, tt.wantState)
+			}
+			if g.CanClaimDraw() != tt.wantClaim {
+				t.Errorf("CanClaimDraw() = %v, want %v", g.CanClaimDraw(), tt.wantClaim)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind identifies the kind of a movetext token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenTag
+	tokenComment
+	tokenNAG
+	tokenOpen
+	tokenClose
+	tokenMove
+	tokenResult
+)
+
+// token is a lexical element of a PGN database
+type token struct {
+	kind  tokenKind
+	text  string // Tag name, comment, move or result
+	value string // Tag value
+	nag   int
+	line  int
+}
+
+// Parser reads the games of a PGN database one at a time
+type Parser struct {
+	r         *bufio.Reader
+	line      int
+	lineStart bool
+	peeked    *token
+}
+
+// NewParser creates a parser reading PGN from r
+func NewParser(r io.Reader) *Parser {
+	return &Parser{
+		r:         bufio.NewReader(r),
+		line:      1,
+		lineStart: true,
+	}
+}
+
+// Parse reads all games from r
+func Parse(r io.Reader) ([]*Game, error) {
+	p := NewParser(r)
+	var games []*Game
+	for {
+		g, err := p.Next()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return games, err
+		}
+		games = append(games, g)
+	}
+}
+
+// Next returns the next game of the database, or io.EOF when there are
+// no more games
+func (p *Parser) Next() (*Game, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind == tokenEOF {
+		return nil, io.EOF
+	}
+
+	g := &Game{Result: "*"}
+	for tok.kind == tokenTag {
+		g.Tags = append(g.Tags, Tag{Name: tok.text, Value: tok.value})
+		if tok, err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+	p.unread(tok)
+
+	if g.Moves, err = p.parseLine(g, 0); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// parseLine parses moves up to the end of a variation, or up to the end
+// of the game when depth is zero
+func (p *Parser) parseLine(g *Game, depth int) ([]*Move, error) {
+	var moves []*Move
+	var pending []string // Comments seen before the first move of the line
+
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+
+		var last *Move
+		if len(moves) > 0 {
+			last = moves[len(moves)-1]
+		}
+
+		switch tok.kind {
+		case tokenMove:
+			moves = append(moves, &Move{SAN: tok.text, LeadingComments: pending})
+			pending = nil
+		case tokenComment:
+			if last == nil {
+				pending = append(pending, tok.text)
+			} else {
+				last.Comments = append(last.Comments, tok.text)
+			}
+		case tokenNAG:
+			if last == nil {
+				return nil, fmt.Errorf("pgn: line %d: annotation glyph before any move", tok.line)
+			}
+			last.NAGs = append(last.NAGs, tok.nag)
+		case tokenOpen:
+			if last == nil {
+				return nil, fmt.Errorf("pgn: line %d: variation before any move", tok.line)
+			}
+			variation, err := p.parseLine(g, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			last.Variations = append(last.Variations, variation)
+		case tokenClose:
+			if depth == 0 {
+				return nil, fmt.Errorf("pgn: line %d: unexpected ')'", tok.line)
+			}
+			return moves, nil
+		case tokenResult, tokenTag, tokenEOF:
+			if depth > 0 {
+				return nil, fmt.Errorf("pgn: line %d: unterminated variation", tok.line)
+			}
+			if tok.kind == tokenResult {
+				g.Result = tok.text
+			} else {
+				// A missing termination marker ends the game at the next
+				// tag section or at the end of the input
+				p.unread(tok)
+			}
+			g.Comments = pending
+			if len(moves) > 0 {
+				g.Comments = moves[0].LeadingComments
+				moves[0].LeadingComments = nil
+			}
+			return moves, nil
+		}
+	}
+}
+
+// unread pushes a token back so the next call to next returns it
+func (p *Parser) unread(tok token) {
+	p.peeked = &tok
+}
+
+// next returns the next token, skipping move numbers and escaped lines
+func (p *Parser) next() (token, error) {
+	if p.peeked != nil {
+		tok := *p.peeked
+		p.peeked = nil
+		return tok, nil
+	}
+
+	for {
+		lineStart := p.lineStart
+		r, err := p.readRune()
+		if err == io.EOF {
+			return token{kind: tokenEOF, line: p.line}, nil
+		}
+		if err != nil {
+			return token{}, err
+		}
+		line := p.line
+
+		switch {
+		case unicode.IsSpace(r) || r == '.':
+			continue
+		case r == '%' && lineStart:
+			// Escape mechanism: the rest of the line is ignored
+			if _, err := p.readUntil('\n'); err != nil && err != io.EOF {
+				return token{}, err
+			}
+		case r == ';':
+			text, err := p.readUntil('\n')
+			if err != nil && err != io.EOF {
+				return token{}, err
+			}
+			return token{kind: tokenComment, text: strings.TrimSpace(text), line: line}, nil
+		case r == '{':
+			text, err := p.readUntil('}')
+			if err == io.EOF {
+				return token{}, fmt.Errorf("pgn: line %d: unterminated comment", line)
+			}
+			if err != nil {
+				return token{}, err
+			}
+			return token{kind: tokenComment, text: strings.TrimSpace(text), line: line}, nil
+		case r == '[':
+			return p.readTag(line)
+		case r == '(':
+			return token{kind: tokenOpen, line: line}, nil
+		case r == ')':
+			return token{kind: tokenClose, line: line}, nil
+		case r == '*':
+			return token{kind: tokenResult, text: "*", line: line}, nil
+		case r == '$':
+			digits, err := p.readWhile(unicode.IsDigit)
+			if err != nil {
+				return token{}, err
+			}
+			nag, err := strconv.Atoi(digits)
+			if err != nil {
+				return token{}, fmt.Errorf("pgn: line %d: invalid annotation glyph $%s", line, digits)
+			}
+			return token{kind: tokenNAG, nag: nag, line: line}, nil
+		case r == '!' || r == '?':
+			rest, err := p.readWhile(func(r rune) bool { return r == '!' || r == '?' })
+			if err != nil {
+				return token{}, err
+			}
+			suffix := string(r) + rest
+			nag, ok := suffixNAGs[suffix]
+			if !ok {
+				return token{}, fmt.Errorf("pgn: line %d: invalid move suffix %q", line, suffix)
+			}
+			return token{kind: tokenNAG, nag: nag, line: line}, nil
+		case isSymbolStart(r) || r == '@': // Pawn drops may start with '@'
+			rest, err := p.readWhile(isSymbolRune)
+			if err != nil {
+				return token{}, err
+			}
+			symbol := string(r) + rest
+
+			switch {
+			case isMoveNumber(symbol):
+				continue
+			case symbol == "1-0" || symbol == "0-1" || symbol == "1/2-1/2":
+				return token{kind: tokenResult, text: symbol, line: line}, nil
+			}
+			return token{kind: tokenMove, text: symbol, line: line}, nil
+		default:
+			return token{}, fmt.Errorf("pgn: line %d: unexpected character %q", line, r)
+		}
+	}
+}
+
+// readTag reads a tag pair after its opening bracket
+func (p *Parser) readTag(line int) (token, error) {
+	if err := p.skipSpace(); err != nil {
+		return token{}, fmt.Errorf("pgn: line %d: unterminated tag", line)
+	}
+	name, err := p.readWhile(isSymbolRune)
+	if err != nil || name == "" {
+		return token{}, fmt.Errorf("pgn: line %d: missing tag name", line)
+	}
+	if err := p.skipSpace(); err != nil {
+		return token{}, fmt.Errorf("pgn: line %d: unterminated tag", line)
+	}
+	if r, err := p.readRune(); err != nil || r != '"' {
+		return token{}, fmt.Errorf("pgn: line %d: missing value for tag %s", line, name)
+	}
+
+	var value strings.Builder
+	for {
+		r, err := p.readRune()
+		if err != nil {
+			return token{}, fmt.Errorf("pgn: line %d: unterminated value for tag %s", line, name)
+		}
+		if r == '"' {
+			break
+		}
+		if r == '\\' {
+			if r, err = p.readRune(); err != nil {
+				return token{}, fmt.Errorf("pgn: line %d: unterminated value for tag %s", line, name)
+			}
+		}
+		value.WriteRune(r)
+	}
+
+	if err := p.skipSpace(); err != nil {
+		return token{}, fmt.Errorf("pgn: line %d: unterminated tag", line)
+	}
+	if r, err := p.readRune(); err != nil || r != ']' {
+		return token{}, fmt.Errorf("pgn: line %d: missing ']' after tag %s", line, name)
+	}
+
+	return token{kind: tokenTag, text: name, value: value.String(), line: line}, nil
+}
+
+// readRune reads a rune and keeps track of line numbers
+func (p *Parser) readRune() (rune, error) {
+	r, _, err := p.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	p.lineStart = r == '\n'
+	if r == '\n' {
+		p.line++
+	}
+	return r, nil
+}
+
+// unreadRune steps back one rune; it must not be a newline
+func (p *Parser) unreadRune() {
+	p.r.UnreadRune()
+	p.lineStart = false
+}
+
+// readUntil reads up to and including delim and returns the text before it
+func (p *Parser) readUntil(delim rune) (string, error) {
+	var sb strings.Builder
+	for {
+		r, err := p.readRune()
+		if err != nil {
+			return sb.String(), err
+		}
+		if r == delim {
+			return sb.String(), nil
+		}
+		sb.WriteRune(r)
+	}
+}
+
+// readWhile reads the runes accepted by f
+func (p *Parser) readWhile(f func(rune) bool) (string, error) {
+	var sb strings.Builder
+	for {
+		r, err := p.readRune()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !f(r) {
+			if r == '\n' {
+				// Keep the newline consumed so line numbers stay correct
+				return sb.String(), nil
+			}
+			p.unreadRune()
+			return sb.String(), nil
+		}
+		sb.WriteRune(r)
+	}
+}
+
+// skipSpace skips white space inside a tag pair
+func (p *Parser) skipSpace() error {
+	_, err := p.readWhile(unicode.IsSpace)
+	if err != nil {
+		return err
+	}
+	if _, err := p.r.Peek(1); err != nil {
+		return errors.New("unexpected end of input")
+	}
+	return nil
+}
+
+// isSymbolStart reports whether r can start a PGN symbol
+func isSymbolStart(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// isSymbolRune reports whether r can continue a PGN symbol
+func isSymbolRune(r rune) bool {
+	return isSymbolStart(r) || strings.ContainsRune("_+#=:-/@", r)
+}
+
+// isMoveNumber reports whether a symbol is a move number indication
+func isMoveNumber(symbol string) bool {
+	for _, r := range symbol {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package pgn
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const database = `[Event "F/S Return Match"]
+[Site "Belgrade, Serbia JUG"]
+[Date "1992.11.04"]
+[Round "29"]
+[White "Fischer, Robert J."]
+[Black "Spassky, Boris V."]
+[Result "1/2-1/2"]
+
+{Opening comment} 1. e4 e5 2. Nf3 Nc6 3. Bb5 {This opening is called the Ruy Lopez.}
+3... a6 $1 4. Ba4!? Nf6 (4... b5 5. Bb3 (5. Bxb5?? axb5) ; rest of line
+5... Nf6) 5. O-O 1/2-1/2
+
+% an escaped line that is ignored
+[Event "Second \"quoted\" game"]
+[White "A"]
+[Black "B"]
+[Result "0-1"]
+
+1.f3 e5 2.g4 Qh4# 0-1
+
+[Event "Unfinished"]
+
+1. d4 d5
+`
+
+func TestParseDatabase(t *testing.T) {
+	games, err := Parse(strings.NewReader(database))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 3 {
+		t.Fatalf("Parse() returned %d games, want 3", len(games))
+	}
+
+	first := games[0]
+	if len(first.Tags) != 7 {
+		t.Errorf("first game has %d tags, want 7", len(first.Tags))
+	}
+	if white, _ := first.Tag("White"); white != "Fischer, Robert J." {
+		t.Errorf("White tag = %q", white)
+	}
+	if first.Result != "1/2-1/2" {
+		t.Errorf("Result = %q, want 1/2-1/2", first.Result)
+	}
+	if !reflect.DeepEqual(first.Comments, []string{"Opening comment"}) {
+		t.Errorf("game comments = %q", first.Comments)
+	}
+
+	wantMain := []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O"}
+	if got := first.MainLine(); !reflect.DeepEqual(got, wantMain) {
+		t.Errorf("MainLine() = %v, want %v", got, wantMain)
+	}
+
+	bb5 := first.Moves[4]
+	if !reflect.DeepEqual(bb5.Comments, []string{"This opening is called the Ruy Lopez."}) {
+		t.Errorf("comments after Bb5 = %q", bb5.Comments)
+	}
+	if !reflect.DeepEqual(first.Moves[5].NAGs, []int{1}) {
+		t.Errorf("NAGs after a6 = %v, want [1]", first.Moves[5].NAGs)
+	}
+	if !reflect.DeepEqual(first.Moves[6].NAGs, []int{5}) {
+		t.Errorf("NAGs after Ba4 = %v, want [5]", first.Moves[6].NAGs)
+	}
+
+	nf6 := first.Moves[7]
+	if len(nf6.Variations) != 1 {
+		t.Fatalf("Nf6 has %d variations, want 1", len(nf6.Variations))
+	}
+	variation := nf6.Variations[0]
+	if len(variation) != 3 || variation[0].SAN != "b5" || variation[2].SAN != "Nf6" {
+		t.Fatalf("unexpected variation %v", variation)
+	}
+	bb3 := variation[1]
+	if !reflect.DeepEqual(bb3.Comments, []string{"rest of line"}) {
+		t.Errorf("comments after Bb3 = %q", bb3.Comments)
+	}
+	if len(bb3.Variations) != 1 || len(bb3.Variations[0]) != 2 {
+		t.Fatalf("nested variation not kept: %v", bb3.Variations)
+	}
+	if !reflect.DeepEqual(bb3.Variations[0][0].NAGs, []int{4}) {
+		t.Errorf("NAGs in nested variation = %v, want [4]", bb3.Variations[0][0].NAGs)
+	}
+
+	second := games[1]
+	if event, _ := second.Tag("Event"); event != `Second "quoted" game` {
+		t.Errorf("Event tag = %q", event)
+	}
+	if got := second.MainLine(); !reflect.DeepEqual(got, []string{"f3", "e5", "g4", "Qh4#"}) {
+		t.Errorf("MainLine() = %v", got)
+	}
+
+	third := games[2]
+	if third.Result != "*" || len(third.Moves) != 2 {
+		t.Errorf("unfinished game parsed as %v with result %q", third.MainLine(), third.Result)
+	}
+}
+
+func TestParserNext(t *testing.T) {
+	p := NewParser(strings.NewReader("1. e4 *\n\n1. d4 *\n"))
+	for i := 0; i < 2; i++ {
+		if _, err := p.Next(); err != nil {
+			t.Fatalf("Next() game %d error = %v", i+1, err)
+		}
+	}
+	if _, err := p.Next(); err != io.EOF {
+		t.Errorf("Next() after last game error = %v, want io.EOF", err)
+	}
+}
+
+func TestParseDrops(t *testing.T) {
+	games, err := Parse(strings.NewReader("[Variant \"Crazyhouse\"]\n\n1. e4 d5 2. exd5 Qxd5 3. P@e4 @e5 *\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []string{"e4", "d5", "exd5", "Qxd5", "P@e4", "@e5"}
+	if got := games[0].MainLine(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MainLine() = %v, want %v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inputs := []string{
+		`[Event "Unterminated`,
+		"1. e4 { never closed",
+		"1. e4 (1. d4 *",
+		"1. e4 ) *",
+		"( 1. e4 ) *",
+		"$1 1. e4 *",
+		"1. e4 !!! *",
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) expected an error", input)
+		}
+	}
+}
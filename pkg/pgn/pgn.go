@@ -0,0 +1,59 @@
+// Package pgn reads chess games in Portable Game Notation.
+//
+// The parser keeps everything a PGN database may contain: tag pairs,
+// brace and semicolon comments, numeric annotation glyphs and nested
+// variations. It does not know the rules of chess; moves are kept as SAN
+// strings and can be replayed with game.NewGameFromPGN.
+package pgn
+
+// Tag is a PGN tag pair
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Game is a single game read from a PGN database
+type Game struct {
+	Tags     []Tag
+	Comments []string // Comments before the first move
+	Moves    []*Move  // The main line
+	Result   string   // The game termination marker, "*" if missing
+}
+
+// Move is a move of the movetext with its annotations
+type Move struct {
+	SAN             string
+	NAGs            []int     // Numeric annotation glyphs, including those written as !, ? etc.
+	LeadingComments []string  // Comments before the move, only used at the start of a variation
+	Comments        []string  // Comments after the move
+	Variations      [][]*Move // Alternatives to this move, each starting in the same position
+}
+
+// Tag returns the value of the named tag
+func (g *Game) Tag(name string) (string, bool) {
+	for _, tag := range g.Tags {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// MainLine returns the SAN moves of the main line
+func (g *Game) MainLine() []string {
+	moves := make([]string, len(g.Moves))
+	for i, move := range g.Moves {
+		moves[i] = move.SAN
+	}
+	return moves
+}
+
+// suffixNAGs maps move suffix annotations to their numeric glyphs
+var suffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
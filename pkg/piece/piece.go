@@ -1,169 +1,177 @@
 package piece
 
 import (
+	"github.com/user/chess/pkg/bitboard"
 	"github.com/user/chess/pkg/board"
 )
 
-// MoveValidator defines an interface for validating piece moves
+// CastlingRights represents the castling rights for a player
+type CastlingRights struct {
+	KingSide  bool
+	QueenSide bool
+}
+
+// State holds the parts of the game state that affect how pieces move
+type State struct {
+	EnPassantTarget *board.Position
+	CastlingRights  map[board.Color]CastlingRights
+}
+
+// MoveValidator defines an interface for validating piece moves. The
+// registered validators answer from the bitboard move generator, so they
+// agree with the moves a game accepts.
 type MoveValidator interface {
-	ValidateMoves(pos board.Position, b *board.Board) []board.Position
+	// ValidateMoves returns the squares the piece at pos can move to,
+	// without checking whether the move leaves its own king in check
+	ValidateMoves(pos board.Position, b *board.Board, state State) []board.Position
+	// Attacks returns the squares the piece at pos attacks
+	Attacks(pos board.Position, b *board.Board) []board.Position
 }
 
-// GetValidMoves returns all valid moves for a piece at the given position
-func GetValidMoves(pos board.Position, b *board.Board) []board.Position {
-	piece := b.GetPiece(pos)
-	var moves []board.Position
-
-	switch piece.Type {
-	case board.Pawn:
-		moves = getValidPawnMoves(pos, piece.Color, b)
-	case board.Knight:
-		moves = getValidKnightMoves(pos, piece.Color, b)
-	case board.Bishop:
-		moves = getValidBishopMoves(pos, piece.Color, b)
-	case board.Rook:
-		moves = getValidRookMoves(pos, piece.Color, b)
-	case board.Queen:
-		bishopMoves := getValidBishopMoves(pos, piece.Color, b)
-		rookMoves := getValidRookMoves(pos, piece.Color, b)
-		moves = append(bishopMoves, rookMoves...)
-	case board.King:
-		moves = getValidKingMoves(pos, piece.Color, b)
-	}
+// validators holds the registered move validator for each piece type
+var validators = map[board.PieceType]MoveValidator{}
 
-	return moves
+func init() {
+	Register(board.Pawn, PawnValidator{})
+	Register(board.Knight, KnightValidator{})
+	Register(board.Bishop, BishopValidator{})
+	Register(board.Rook, RookValidator{})
+	Register(board.Queen, QueenValidator{})
+	Register(board.King, KingValidator{})
 }
 
-// Helper functions for each piece type
-func getValidPawnMoves(pos board.Position, color board.Color, b *board.Board) []board.Position {
-	var moves []board.Position
+// Register sets the move validator used for a piece type
+func Register(pieceType board.PieceType, validator MoveValidator) {
+	validators[pieceType] = validator
+}
 
-	// Determine the direction pawns move based on their color
-	forwardDir := -1
-	startRow := 6
-	if color == board.Black {
-		forwardDir = 1
-		startRow = 1
-	}
+// Validator returns the move validator registered for a piece type
+func Validator(pieceType board.PieceType) (MoveValidator, bool) {
+	validator, ok := validators[pieceType]
+	return validator, ok
+}
 
-	// Check one square forward
-	forwardPos := board.Position{Row: pos.Row + forwardDir, Col: pos.Col}
-	if isValidPosition(forwardPos) && b.IsEmpty(forwardPos) {
-		moves = append(moves, forwardPos)
-
-		// Check two squares forward from starting position
-		if pos.Row == startRow {
-			twoForwardPos := board.Position{Row: pos.Row + 2*forwardDir, Col: pos.Col}
-			if b.IsEmpty(twoForwardPos) {
-				moves = append(moves, twoForwardPos)
-			}
-		}
+// GetValidMoves returns all valid moves for a piece at the given position
+func GetValidMoves(pos board.Position, b *board.Board, state State) []board.Position {
+	validator, ok := Validator(b.GetPiece(pos).Type)
+	if !ok {
+		return nil
 	}
+	return validator.ValidateMoves(pos, b, state)
+}
 
-	// Check diagonal captures
-	for _, colOffset := range []int{-1, 1} {
-		capturePos := board.Position{Row: pos.Row + forwardDir, Col: pos.Col + colOffset}
-		if isValidPosition(capturePos) {
-			capturePiece := b.GetPiece(capturePos)
-			if capturePiece.Type != board.Empty && capturePiece.Color != color {
-				moves = append(moves, capturePos)
-			}
+// IsSquareAttacked checks if any piece of the given color attacks pos
+func IsSquareAttacked(pos board.Position, by board.Color, b *board.Board) bool {
+	p := bitboard.NewPosition(b, by)
+	return p.IsAttacked(bitboard.SquareOf(pos), by)
+}
+
+// generated answers ValidateMoves from the moves the bitboard generator
+// finds for the piece. The validators embed it and differ in Attacks.
+type generated struct{}
+
+// ValidateMoves implements MoveValidator
+func (generated) ValidateMoves(pos board.Position, b *board.Board, state State) []board.Position {
+	p := position(b, b.GetPiece(pos).Color, state)
+	from := bitboard.SquareOf(pos)
+
+	// Promotions give one move per piece, but one target square. The
+	// generator leaves the square a castling king lands on to the check
+	// for legal moves, so it is checked here.
+	var targets bitboard.Bitboard
+	for _, m := range p.PseudoLegalMoves(nil) {
+		if m.From != from || (p.CastlingRight(m) != bitboard.NoCastling && !p.IsLegal(m)) {
+			continue
 		}
+		targets |= m.To.Bitboard()
 	}
+	return squares(targets)
+}
 
-	return moves
+// PawnValidator validates pawn moves, including en passant
+type PawnValidator struct{ generated }
+
+// Attacks implements MoveValidator
+func (PawnValidator) Attacks(pos board.Position, b *board.Board) []board.Position {
+	return squares(bitboard.PawnAttacks(bitboard.SquareOf(pos), b.GetPiece(pos).Color))
 }
 
-func getValidKnightMoves(pos board.Position, color board.Color, b *board.Board) []board.Position {
-	var moves []board.Position
+// KnightValidator validates knight moves
+type KnightValidator struct{ generated }
 
-	// Knight moves in L-shape
-	knightOffsets := []struct{ row, col int }{
-		{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2},
-		{1, -2}, {1, 2}, {2, -1}, {2, 1},
-	}
+// Attacks implements MoveValidator
+func (KnightValidator) Attacks(pos board.Position, b *board.Board) []board.Position {
+	return squares(bitboard.KnightAttacks(bitboard.SquareOf(pos)))
+}
 
-	for _, offset := range knightOffsets {
-		newPos := board.Position{Row: pos.Row + offset.row, Col: pos.Col + offset.col}
-		if isValidPosition(newPos) {
-			piece := b.GetPiece(newPos)
-			if piece.Type == board.Empty || piece.Color != color {
-				moves = append(moves, newPos)
-			}
-		}
-	}
+// BishopValidator validates bishop moves
+type BishopValidator struct{ generated }
 
-	return moves
+// Attacks implements MoveValidator
+func (BishopValidator) Attacks(pos board.Position, b *board.Board) []board.Position {
+	return squares(bitboard.BishopAttacks(bitboard.SquareOf(pos), occupied(b)))
 }
 
-func getValidBishopMoves(pos board.Position, color board.Color, b *board.Board) []board.Position {
-	// Bishop moves diagonally
-	directions := []struct{ rowDir, colDir int }{
-		{-1, -1}, {-1, 1}, {1, -1}, {1, 1},
-	}
+// RookValidator validates rook moves
+type RookValidator struct{ generated }
 
-	return getSlidingMoves(pos, color, b, directions)
+// Attacks implements MoveValidator
+func (RookValidator) Attacks(pos board.Position, b *board.Board) []board.Position {
+	return squares(bitboard.RookAttacks(bitboard.SquareOf(pos), occupied(b)))
 }
 
-func getValidRookMoves(pos board.Position, color board.Color, b *board.Board) []board.Position {
-	// Rook moves horizontally and vertically
-	directions := []struct{ rowDir, colDir int }{
-		{-1, 0}, {1, 0}, {0, -1}, {0, 1},
-	}
+// QueenValidator validates queen moves
+type QueenValidator struct{ generated }
 
-	return getSlidingMoves(pos, color, b, directions)
+// Attacks implements MoveValidator
+func (QueenValidator) Attacks(pos board.Position, b *board.Board) []board.Position {
+	return squares(bitboard.QueenAttacks(bitboard.SquareOf(pos), occupied(b)))
 }
 
-func getValidKingMoves(pos board.Position, color board.Color, b *board.Board) []board.Position {
-	var moves []board.Position
+// KingValidator validates king moves, including castling
+type KingValidator struct{ generated }
 
-	// King moves one square in any direction
-	for rowOffset := -1; rowOffset <= 1; rowOffset++ {
-		for colOffset := -1; colOffset <= 1; colOffset++ {
-			if rowOffset == 0 && colOffset == 0 {
-				continue
-			}
+// Attacks implements MoveValidator
+func (KingValidator) Attacks(pos board.Position, b *board.Board) []board.Position {
+	return squares(bitboard.KingAttacks(bitboard.SquareOf(pos)))
+}
 
-			newPos := board.Position{Row: pos.Row + rowOffset, Col: pos.Col + colOffset}
-			if isValidPosition(newPos) {
-				piece := b.GetPiece(newPos)
-				if piece.Type == board.Empty || piece.Color != color {
-					moves = append(moves, newPos)
-				}
-			}
-		}
-	}
+// HomeRow returns the row on which the pieces of a color start
+func HomeRow(color board.Color) int {
+	return bitboard.HomeRow(color)
+}
 
-	return moves
-}
-
-// Helper function for sliding pieces (bishop, rook, queen)
-func getSlidingMoves(pos board.Position, color board.Color, b *board.Board, directions []struct{ rowDir, colDir int }) []board.Position {
-	var moves []board.Position
-
-	for _, dir := range directions {
-		for i := 1; i < 8; i++ {
-			newPos := board.Position{Row: pos.Row + i*dir.rowDir, Col: pos.Col + i*dir.colDir}
-			if !isValidPosition(newPos) {
-				break
-			}
-
-			piece := b.GetPiece(newPos)
-			if piece.Type == board.Empty {
-				moves = append(moves, newPos)
-			} else if piece.Color != color {
-				moves = append(moves, newPos)
-				break
-			} else {
-				break
-			}
+// position returns the bitboard position of b with the given side to move
+// and the castling rights and en passant target of state
+func position(b *board.Board, side board.Color, state State) bitboard.Position {
+	p := bitboard.NewPosition(b, side)
+	rights := bitboard.NoCastling
+	for color, r := range state.CastlingRights {
+		if r.KingSide {
+			rights |= bitboard.KingSide(color)
+		}
+		if r.QueenSide {
+			rights |= bitboard.QueenSide(color)
 		}
 	}
+	p.SetCastling(rights)
+	if state.EnPassantTarget != nil {
+		p.SetEnPassant(bitboard.SquareOf(*state.EnPassantTarget))
+	}
+	return p
+}
 
-	return moves
+// occupied returns the squares of b that hold a piece
+func occupied(b *board.Board) bitboard.Bitboard {
+	p := bitboard.NewPosition(b, board.White)
+	return p.Occupied()
 }
 
-// Helper function to check if a position is on the board
-func isValidPosition(pos board.Position) bool {
-	return pos.Row >= 0 && pos.Row < 8 && pos.Col >= 0 && pos.Col < 8
+// squares lists the squares of a bitboard
+func squares(bb bitboard.Bitboard) []board.Position {
+	var positions []board.Position
+	for bb != 0 {
+		positions = append(positions, bb.PopFirst().Position())
+	}
+	return positions
 }
@@ -0,0 +1,252 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/game"
+)
+
+// BughouseUI plays a Bughouse match on one terminal. Both boards are shown
+// side by side, the second one turned around so that partners sit on the
+// same side. The four players share the keyboard and start each move or
+// command with the number of their board, as in "1 e4" or "2 N@f3".
+type BughouseUI struct {
+	match    *game.Match
+	scanner  *bufio.Scanner
+	useAscii bool
+	names    [2][3]string // The players of each board, indexed by color
+}
+
+// NewBughouseUI creates a UI for a match
+func NewBughouseUI(m *game.Match) *BughouseUI {
+	ui := &BughouseUI{
+		match:   m,
+		scanner: bufio.NewScanner(os.Stdin),
+	}
+	for i := range ui.names {
+		ui.names[i][board.White] = "White"
+		ui.names[i][board.Black] = "Black"
+	}
+	return ui
+}
+
+// SetAsciiMode sets whether to use ASCII characters instead of Unicode
+func (ui *BughouseUI) SetAsciiMode(ascii bool) {
+	ui.useAscii = ascii
+}
+
+// SetPlayerNames sets the names of the players of board 0 or 1
+func (ui *BughouseUI) SetPlayerNames(i int, white, black string) {
+	ui.names[i][board.White] = white
+	ui.names[i][board.Black] = black
+	ui.match.Board(i).SetPlayerNames(white, black)
+}
+
+// Start starts the UI
+func (ui *BughouseUI) Start() {
+	fmt.Println("Welcome to Chess in Go!")
+	fmt.Printf("Bughouse: %s vs %s\n", ui.teamName(0), ui.teamName(1))
+	for i := 0; i < 2; i++ {
+		fmt.Printf("Board %d: %s (White) vs %s (Black)\n", i+1, ui.names[i][board.White], ui.names[i][board.Black])
+	}
+	fmt.Println("Pieces you capture go to your partner, who drops them with 'N@f3' or 'P@e4'")
+	fmt.Println("Start each move with your board number (e.g., '1 e4', '2 Nf3', '1 N@f3')")
+	fmt.Println("Pawns promote to a queen unless the move names another piece (e.g., '1 e8=N')")
+	fmt.Println("Type '1 resign' or '2 resign' to resign, '1 draw' to offer a draw on board 1,")
+	fmt.Println("and '1 accept' or '1 decline' to answer an offer. The match ends with the first board")
+	fmt.Println("Type 'quit' to exit")
+
+	for {
+		ui.printBoards()
+		for i := 0; i < 2; i++ {
+			fmt.Printf("Board %d: %s\n", i+1, ui.boardStatus(i))
+		}
+
+		if ui.match.IsOver() {
+			break
+		}
+		if !ui.getMove() {
+			break
+		}
+	}
+
+	ui.printGameOver()
+}
+
+// printBoards prints both boards side by side with their pockets
+func (ui *BughouseUI) printBoards() {
+	left := ui.boardLines(0, false)
+	right := ui.boardLines(1, true)
+
+	width := 0
+	for _, line := range left {
+		if n := utf8.RuneCountInString(line); n > width {
+			width = n
+		}
+	}
+	for row, line := range left {
+		padding := strings.Repeat(" ", width-utf8.RuneCountInString(line)+4)
+		fmt.Println(line + padding + right[row])
+	}
+}
+
+// boardLines returns the lines of a board with the pocket of each color
+// on its side. A flipped board has Black at the bottom.
+func (ui *BughouseUI) boardLines(i int, flipped bool) []string {
+	g := ui.match.Board(i)
+	return g.Board.Lines(board.PrintOptions{
+		ASCII:   ui.useAscii,
+		Flipped: flipped,
+		Pockets: &[2]board.Pocket{g.Pocket(board.White), g.Pocket(board.Black)},
+	})
+}
+
+// boardStatus returns who is to move on a board and their time, or how the
+// game on it ended
+func (ui *BughouseUI) boardStatus(i int) string {
+	g := ui.match.Board(i)
+	state := g.GetGameStatus()
+	if g.IsOver() {
+		return state
+	}
+	if g.DrawOffer() != board.NoColor && g.DrawOffer() != g.CurrentPlayer {
+		state += ", draw offered"
+	}
+
+	name := ui.names[i][g.CurrentPlayer]
+	if timeLeft := g.GetTimeLeft(); timeLeft != "" {
+		return fmt.Sprintf("%s's turn [%s] (%s)", name, timeLeft, state)
+	}
+	return fmt.Sprintf("%s's turn (%s)", name, state)
+}
+
+// getMove reads and plays one move or command. It returns false when the
+// players quit or input ends.
+func (ui *BughouseUI) getMove() bool {
+	for {
+		fmt.Print("Enter move: ")
+		if !ui.scanner.Scan() {
+			// End of input
+			return false
+		}
+		input := strings.TrimSpace(ui.scanner.Text())
+		if input == "quit" {
+			return false
+		}
+
+		fields := strings.Fields(input)
+		if len(fields) < 2 || (fields[0] != "1" && fields[0] != "2") {
+			fmt.Println("Start with the board number, e.g. '1 e4' or '2 N@f3'")
+			continue
+		}
+		i := int(fields[0][0] - '1')
+		command := strings.Join(fields[1:], " ")
+
+		var err error
+		switch command {
+		case "resign", "draw", "accept", "decline":
+			err = ui.endGameCommand(i, command)
+		default:
+			err = ui.playMove(i, command)
+		}
+		if err != nil {
+			if ui.match.IsOver() {
+				// A clock ran out before the move was made
+				fmt.Println(err)
+				return true
+			}
+			fmt.Printf("Cannot play %q on board %d: %v\n", command, i+1, err)
+			continue
+		}
+		return true
+	}
+}
+
+// playMove plays a move on a board
+func (ui *BughouseUI) playMove(i int, text string) error {
+	move, err := ui.match.Board(i).ParseMove(text)
+	if err != nil {
+		return err
+	}
+	return ui.match.PlayMove(i, move)
+}
+
+// endGameCommand handles the commands that resign, offer or answer a draw
+// on a board. They act for the player to move there.
+func (ui *BughouseUI) endGameCommand(i int, command string) error {
+	g := ui.match.Board(i)
+	color := g.CurrentPlayer
+	name := ui.names[i][color]
+	switch command {
+	case "resign":
+		if !ui.confirm(fmt.Sprintf("%s, do you really want to resign?", name)) {
+			return nil
+		}
+		return g.Resign(color)
+	case "draw":
+		if err := g.OfferDraw(color); err != nil {
+			return err
+		}
+		fmt.Printf("%s offers a draw\n", name)
+		return nil
+	case "accept":
+		return g.AcceptDraw(color)
+	case "decline":
+		if g.DrawOffer() == color {
+			return fmt.Errorf("%s cannot decline their own offer", name)
+		}
+		if err := g.DeclineDraw(color); err != nil {
+			return err
+		}
+		fmt.Println("Draw offer declined")
+		return nil
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// confirm asks a yes or no question, treating anything but yes as no
+func (ui *BughouseUI) confirm(question string) bool {
+	fmt.Printf("%s (y/n): ", question)
+	if !ui.scanner.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(ui.scanner.Text()))
+	return answer == "y" || answer == "yes"
+}
+
+// printGameOver shows the result of the match
+func (ui *BughouseUI) printGameOver() {
+	i, result := ui.match.Result()
+	if !result.IsOver() {
+		fmt.Println("Game over")
+		return
+	}
+
+	fmt.Printf("Game over on board %d: %s %s %s\n", i+1, ui.names[i][board.White], result.Outcome, ui.names[i][board.Black])
+	switch result.Outcome {
+	case game.WhiteWins:
+		fmt.Printf("%s win the match\n", ui.teamName(ui.match.Team(i, board.White)))
+	case game.BlackWins:
+		fmt.Printf("%s win the match\n", ui.teamName(ui.match.Team(i, board.Black)))
+	default:
+		fmt.Println("The match is drawn")
+	}
+}
+
+// teamName returns the names of the two players of a team
+func (ui *BughouseUI) teamName(team int) string {
+	var names []string
+	for i := 0; i < 2; i++ {
+		for _, color := range []board.Color{board.White, board.Black} {
+			if ui.match.Team(i, color) == team {
+				names = append(names, ui.names[i][color])
+			}
+		}
+	}
+	return strings.Join(names, " and ")
+}
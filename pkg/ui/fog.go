@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"github.com/user/chess/pkg/bitboard"
+	"github.com/user/chess/pkg/board"
+)
+
+// printMaskedBoard prints the board with the squares outside visible
+// shaded, as a Fog of War player sees it
+func printMaskedBoard(b *board.Board, visible bitboard.Bitboard, ascii bool) {
+	b.PrintWith(board.PrintOptions{ASCII: ascii, Hidden: hiddenSquares(visible)})
+}
+
+// hiddenSquares returns the squares outside visible, as the board printer
+// takes them
+func hiddenSquares(visible bitboard.Bitboard) [8][8]bool {
+	var hidden [8][8]bool
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			hidden[row][col] = !visible.Has(bitboard.SquareAt(row, col))
+		}
+	}
+	return hidden
+}
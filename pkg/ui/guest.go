@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/game"
+)
+
+// GuestUI plays Black in a hidden game hosted on another computer. It holds
+// no game of its own: it shows what the host sends and passes on what its
+// player types, so the guest's computer never learns more than its player
+// may see.
+type GuestUI struct {
+	conn     *Connection
+	game     *HiddenGame
+	scanner  *bufio.Scanner
+	useAscii bool
+	moves    []string // The legal moves the host sent for the current turn, in Fog of War
+}
+
+// NewGuestUI creates a UI for the guest of a hidden game
+func NewGuestUI(conn *Connection, g *HiddenGame) *GuestUI {
+	return &GuestUI{
+		conn:    conn,
+		game:    g,
+		scanner: bufio.NewScanner(os.Stdin),
+	}
+}
+
+// SetAsciiMode sets whether to use ASCII characters instead of Unicode
+func (ui *GuestUI) SetAsciiMode(ascii bool) {
+	ui.useAscii = ascii
+}
+
+// Start plays the game until the host ends it or the connection is lost
+func (ui *GuestUI) Start() {
+	fmt.Println("Welcome to Chess in Go!")
+	fmt.Printf("Players: %s (White) vs %s (Black)\n", ui.game.WhiteName, ui.game.BlackName)
+	fmt.Printf("Variant: %s\n", ui.game.Variant.Name())
+	fmt.Println("Enter moves in algebraic notation (e.g., 'e4', 'Nf3', 'O-O', 'e8=Q') or as squares (e.g., 'e2 e4', 'e7e8q')")
+	printVariantRules(ui.game.Variant)
+	fmt.Printf("Playing over the network; %s hosts the game and moves on the other computer\n", ui.game.WhiteName)
+	if ui.game.Variant == game.FogOfWar {
+		fmt.Println("Type 'moves' to list your legal moves")
+	}
+	fmt.Println("Type 'resign' to resign, 'draw' to offer a draw, 'accept' or 'decline' to answer an offer")
+	fmt.Println("and 'claim' to claim a draw by threefold repetition or the 50-move rule")
+	fmt.Println("Type 'quit' to exit")
+
+	for {
+		line, err := ui.conn.Receive()
+		if err != nil {
+			fmt.Println("Connection lost:", err)
+			return
+		}
+		switch {
+		case line == overLine:
+			return
+		case line == promptLine:
+			ui.prompt()
+		case strings.HasPrefix(line, viewPrefix):
+			b, hidden, err := parseView(line)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			b.PrintWith(board.PrintOptions{ASCII: ui.useAscii, Hidden: hidden})
+		case strings.HasPrefix(line, sayPrefix):
+			fmt.Println(strings.TrimPrefix(line, sayPrefix))
+		case strings.HasPrefix(line, movesPrefix):
+			ui.moves = strings.Fields(strings.TrimPrefix(line, movesPrefix))
+		}
+	}
+}
+
+// prompt reads what the player types on their turn and sends it to the
+// host. The player leaves at the end of input, and the host then sends the
+// result.
+func (ui *GuestUI) prompt() {
+	for {
+		fmt.Print("Enter move: ")
+		input := "quit"
+		if ui.scanner.Scan() {
+			input = strings.TrimSpace(ui.scanner.Text())
+		}
+
+		switch input {
+		case "moves":
+			if ui.game.Variant == game.FogOfWar {
+				fmt.Println("Legal moves:", strings.Join(ui.moves, " "))
+				continue
+			}
+		case "undo", "redo":
+			fmt.Printf("Cannot %s: moves cannot be taken back in a network game\n", input)
+			continue
+		case "resign":
+			if !ui.confirm("Do you really want to resign?") {
+				continue
+			}
+		}
+		if err := ui.conn.Send(input); err != nil {
+			fmt.Println("Connection lost:", err)
+		}
+		return
+	}
+}
+
+// confirm asks a yes or no question, treating anything but yes as no
+func (ui *GuestUI) confirm(question string) bool {
+	fmt.Printf("%s (y/n): ", question)
+	if !ui.scanner.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(ui.scanner.Text()))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,293 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/game"
+	"github.com/user/chess/pkg/pgn"
+)
+
+// A network game links two copies of the program over TCP. The host sends
+// the game to the player who connects: a version line, the time control
+// and the game in PGN. After that each side sends what its player types,
+// one line per move or command, and both sides play it on their own copy
+// of the game.
+//
+// The host alone keeps time. Before each of its moves it sends the time
+// left on both clocks, and the guest sets its clocks to them once it has
+// played the move, so that both sides see a flag fall on the same move.
+//
+// In a hidden game, of a variant that hides the opponent's pieces, the
+// host alone holds the game. It sends the variant and the names instead of
+// the PGN, and after that only what the guest's player may know: their
+// view of the board, the referee's announcements in Kriegspiel, their
+// legal moves in Fog of War and the messages meant for them, with a prompt
+// whenever the guest is to move. The guest sends back what
+// its player types, and GuestUI shows the rest.
+
+// protocolVersion is the first line the host sends, so that a copy of the
+// program that speaks another protocol gives up at once
+const protocolVersion = "chess-go 1"
+
+// clockPrefix starts the line in which the host sends the time left on
+// both clocks, in milliseconds
+const clockPrefix = "clock "
+
+// The lines the host sends the guest of a hidden game
+const (
+	viewPrefix  = "board " // The guest's view of the board, as sent by SendView
+	sayPrefix   = "say "   // A message for the guest's player
+	movesPrefix = "moves"  // The guest's legal moves in SAN, separated by spaces
+	promptLine  = "prompt" // The guest's player is to enter a move or command
+	overLine    = "over"   // The game is over and nothing more will be sent
+)
+
+// Connection links the UI to an opponent playing on another computer
+type Connection struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	host   bool // Whether this side hosts the game and keeps time
+}
+
+// isHidden reports whether games of a variant are played as hidden games
+// over the network, with the guest seeing only its own view
+func isHidden(v game.Variant) bool {
+	return v == game.Kriegspiel || v == game.FogOfWar
+}
+
+// HiddenGame is a game hosted on another computer as a hidden game. The
+// guest knows only the variant and the players until the host sends more.
+type HiddenGame struct {
+	Variant   game.Variant
+	WhiteName string
+	BlackName string
+}
+
+// Host waits for an opponent to connect to addr, such as ":7777"
+func Host(addr string) (*Connection, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	c := newConnection(conn)
+	c.host = true
+	return c, nil
+}
+
+// Connect joins a game hosted at addr, such as "localhost:7777"
+func Connect(addr string) (*Connection, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return newConnection(conn), nil
+}
+
+func newConnection(conn net.Conn) *Connection {
+	return &Connection{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// SendGame sends the game and its time control to the opponent. The clocks
+// start again from the initial time. A hidden game is sent without its
+// moves.
+func (c *Connection) SendGame(g *game.Game) error {
+	timeControl := "none"
+	if tc := g.TimeControl; tc != nil {
+		timeControl = fmt.Sprintf("%d %d", tc.InitialTime/time.Minute, tc.IncrementPerMove/time.Second)
+	}
+	if isHidden(g.Variant()) {
+		_, err := fmt.Fprintf(c.conn, "%s\ntime %s\nhidden %s\nwhite %s\nblack %s\n",
+			protocolVersion, timeControl, g.Variant().Name(), g.WhitePlayer, g.BlackPlayer)
+		return err
+	}
+
+	var pgnText strings.Builder
+	if err := g.WritePGN(&pgnText, nil); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(c.conn, "%s\ntime %s\npgn %d\n%s", protocolVersion, timeControl, pgnText.Len(), pgnText.String())
+	return err
+}
+
+// ReceiveGame reads the game sent by the host. A hidden game is returned
+// as a HiddenGame instead, whose clocks the host keeps.
+func (c *Connection) ReceiveGame() (*game.Game, *HiddenGame, error) {
+	version, err := c.Receive()
+	if err != nil {
+		return nil, nil, err
+	}
+	if version != protocolVersion {
+		return nil, nil, fmt.Errorf("unsupported protocol %q", version)
+	}
+
+	line, err := c.Receive()
+	if err != nil {
+		return nil, nil, err
+	}
+	var timeControl *game.TimeControl
+	if line != "time none" {
+		var minutes, increment int
+		if _, err := fmt.Sscanf(line, "time %d %d", &minutes, &increment); err != nil {
+			return nil, nil, fmt.Errorf("invalid time control %q", line)
+		}
+		timeControl = game.NewTimeControl(minutes, increment)
+	}
+
+	line, err = c.Receive()
+	if err != nil {
+		return nil, nil, err
+	}
+	if name, ok := strings.CutPrefix(line, "hidden "); ok {
+		hidden, err := c.receiveHiddenGame(name)
+		return nil, hidden, err
+	}
+	var length int
+	if _, err := fmt.Sscanf(line, "pgn %d", &length); err != nil || length < 0 {
+		return nil, nil, fmt.Errorf("invalid game header %q", line)
+	}
+	pgnText := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, pgnText); err != nil {
+		return nil, nil, err
+	}
+
+	pg, err := pgn.NewParser(bytes.NewReader(pgnText)).Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := game.NewGameFromPGN(pg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isHidden(g.Variant()) {
+		return nil, nil, fmt.Errorf("%s was sent with its moves", g.Variant().Name())
+	}
+	g.TimeControl = timeControl
+	return g, nil, nil
+}
+
+// receiveHiddenGame reads the players of a hidden game of the named
+// variant
+func (c *Connection) receiveHiddenGame(variantName string) (*HiddenGame, error) {
+	variant, err := game.ParseVariant(variantName)
+	if err != nil {
+		return nil, err
+	}
+	if !isHidden(variant) {
+		return nil, fmt.Errorf("%s cannot be played as a hidden game", variant.Name())
+	}
+	hidden := &HiddenGame{Variant: variant}
+	for _, field := range []struct {
+		prefix string
+		name   *string
+	}{{"white ", &hidden.WhiteName}, {"black ", &hidden.BlackName}} {
+		line, err := c.Receive()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := strings.CutPrefix(line, field.prefix)
+		if !ok {
+			return nil, fmt.Errorf("invalid player %q", line)
+		}
+		*field.name = name
+	}
+	return hidden, nil
+}
+
+// Send sends a line typed by the local player
+func (c *Connection) Send(line string) error {
+	_, err := fmt.Fprintln(c.conn, line)
+	return err
+}
+
+// IsHost reports whether this side hosts the game
+func (c *Connection) IsHost() bool {
+	return c.host
+}
+
+// SendClock sends the time left on both clocks to the guest
+func (c *Connection) SendClock(tc *game.TimeControl) error {
+	_, err := fmt.Fprintf(c.conn, "%s%d %d\n", clockPrefix, tc.WhiteTimeLeft.Milliseconds(), tc.BlackTimeLeft.Milliseconds())
+	return err
+}
+
+// parseClock reads the times in a line sent by SendClock
+func parseClock(line string) (white, black time.Duration, err error) {
+	var whiteMillis, blackMillis int64
+	if _, err := fmt.Sscanf(line, clockPrefix+"%d %d", &whiteMillis, &blackMillis); err != nil {
+		return 0, 0, fmt.Errorf("invalid clock %q", line)
+	}
+	return time.Duration(whiteMillis) * time.Millisecond, time.Duration(blackMillis) * time.Millisecond, nil
+}
+
+// SendView sends the guest of a hidden game its view of the board: the
+// pieces by their FEN letters, "." for an empty square and "?" for a
+// hidden one, from a8 to h1
+func (c *Connection) SendView(b *board.Board, hidden [8][8]bool) error {
+	var sb strings.Builder
+	sb.WriteString(viewPrefix)
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			switch piece := b.Squares[row][col]; {
+			case hidden[row][col]:
+				sb.WriteByte('?')
+			case piece.Type == board.Empty:
+				sb.WriteByte('.')
+			default:
+				sb.WriteString(piece.ASCIIString())
+			}
+		}
+	}
+	return c.Send(sb.String())
+}
+
+// parseView reads the board and hidden squares in a line sent by SendView
+func parseView(line string) (*board.Board, [8][8]bool, error) {
+	b := &board.Board{}
+	var hidden [8][8]bool
+	squares := strings.TrimPrefix(line, viewPrefix)
+	if len(squares) != 64 {
+		return nil, hidden, fmt.Errorf("invalid board %q", line)
+	}
+	for i, symbol := range squares {
+		row, col := i/8, i%8
+		switch symbol {
+		case '?':
+			hidden[row][col] = true
+		case '.':
+		default:
+			piece, err := board.ParsePiece(symbol)
+			if err != nil {
+				return nil, hidden, fmt.Errorf("invalid board %q", line)
+			}
+			b.Squares[row][col] = piece
+		}
+	}
+	return b, hidden, nil
+}
+
+// Receive waits for a line from the opponent
+func (c *Connection) Receive() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Close ends the connection
+func (c *Connection) Close() error {
+	return c.conn.Close()
+}
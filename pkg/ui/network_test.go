@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/chess/pkg/board"
+	"github.com/user/chess/pkg/game"
+)
+
+func TestSendGame(t *testing.T) {
+	g := game.NewGame(game.WithVariant(game.Crazyhouse))
+	g.SetPlayerNames("Alice", "Bob")
+	g.TimeControl = game.NewTimeControl(3, 2)
+	for _, san := range []string{"e4", "e5", "Nf3"} {
+		move, err := g.ParseMove(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.PlayMove(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hostConn, clientConn := net.Pipe()
+	host, client := newConnection(hostConn), newConnection(clientConn)
+	defer host.Close()
+	defer client.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		if err := host.SendGame(g); err != nil {
+			errs <- err
+			return
+		}
+		errs <- host.Send("Nc6")
+	}()
+
+	received, hidden, err := client.ReceiveGame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hidden != nil {
+		t.Fatal("ReceiveGame() returned a hidden game of Crazyhouse")
+	}
+	if received.FEN() != g.FEN() {
+		t.Errorf("FEN = %q, want %q", received.FEN(), g.FEN())
+	}
+	if received.Variant() != game.Crazyhouse {
+		t.Errorf("variant = %s, want Crazyhouse", received.Variant().Name())
+	}
+	if received.WhitePlayer != "Alice" || received.BlackPlayer != "Bob" {
+		t.Errorf("players = %q and %q, want Alice and Bob", received.WhitePlayer, received.BlackPlayer)
+	}
+	if tc := received.TimeControl; tc == nil || tc.InitialTime != 3*time.Minute || tc.IncrementPerMove != 2*time.Second {
+		t.Errorf("time control = %+v, want 3 minutes and 2 seconds", tc)
+	}
+
+	line, err := client.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "Nc6" {
+		t.Errorf("Receive() = %q, want %q", line, "Nc6")
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReceiveGamePrivateVariant(t *testing.T) {
+	hostConn, clientConn := net.Pipe()
+	host, client := newConnection(hostConn), newConnection(clientConn)
+	defer host.Close()
+	defer client.Close()
+
+	pgnText := "[Variant \"Fog of War\"]\n\n1. e4 *\n"
+	go fmt.Fprintf(hostConn, "%s\ntime none\npgn %d\n%s", protocolVersion, len(pgnText), pgnText)
+
+	if _, _, err := client.ReceiveGame(); err == nil {
+		t.Error("ReceiveGame() accepted a game of Fog of War with its moves")
+	}
+}
+
+func TestSendHiddenGame(t *testing.T) {
+	g := game.NewGame(game.WithVariant(game.FogOfWar))
+	g.SetPlayerNames("Alice", "Bob Smith")
+	hostConn, clientConn := net.Pipe()
+	host, client := newConnection(hostConn), newConnection(clientConn)
+	defer host.Close()
+	defer client.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- host.SendGame(g)
+	}()
+
+	received, hidden, err := client.ReceiveGame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != nil || hidden == nil {
+		t.Fatal("ReceiveGame() did not return a hidden game")
+	}
+	if want := (HiddenGame{Variant: game.FogOfWar, WhiteName: "Alice", BlackName: "Bob Smith"}); *hidden != want {
+		t.Errorf("ReceiveGame() = %+v, want %+v", *hidden, want)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendView(t *testing.T) {
+	hostConn, clientConn := net.Pipe()
+	host, client := newConnection(hostConn), newConnection(clientConn)
+	defer host.Close()
+	defer client.Close()
+
+	b := board.NewBoard()
+	var hidden [8][8]bool
+	hidden[7][4] = true // e1
+	go host.SendView(b, hidden)
+
+	line, err := client.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, gotHidden, err := parseView(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotHidden != hidden {
+		t.Errorf("parseView(%q) hidden = %v, want %v", line, gotHidden, hidden)
+	}
+	if got.Squares[7][4] != (board.Piece{}) {
+		t.Errorf("parseView(%q) shows the hidden king", line)
+	}
+	got.Squares[7][4] = b.Squares[7][4]
+	if *got != *b {
+		t.Errorf("parseView(%q) = %v, want %v", line, got.Squares, b.Squares)
+	}
+}
+
+// playHiddenGame hosts a hidden game of the variant with the local player
+// as White, typing hostInput, and plays Black from the other end of the
+// connection with guestInput, one line per prompt. It returns the lines
+// the guest received before the game ended.
+func playHiddenGame(t *testing.T, variant game.Variant, hostInput string, guestInput ...string) []string {
+	t.Helper()
+	hostConn, guestConn := net.Pipe()
+	guest := newConnection(guestConn)
+	defer guest.Close()
+
+	g := game.NewGame(game.WithVariant(variant))
+	g.TimeControl = nil
+	ui := NewUI(g)
+	ui.scanner = bufio.NewScanner(strings.NewReader(hostInput))
+	ui.SetRemote(board.Black, newConnection(hostConn))
+
+	received := make(chan []string, 1)
+	go func() {
+		var lines []string
+		for {
+			line, err := guest.Receive()
+			if err != nil || line == overLine {
+				received <- lines
+				return
+			}
+			lines = append(lines, line)
+			if line == promptLine && len(guestInput) > 0 {
+				guest.Send(guestInput[0])
+				guestInput = guestInput[1:]
+			}
+		}
+	}()
+
+	ui.Start()
+	hostConn.Close()
+	if !g.IsOver() {
+		t.Fatalf("game not over, result %v", g.Result())
+	}
+	return <-received
+}
+
+// beforeReveal returns the lines the guest received before the last view
+// of the board, which shows the whole board once the game is over
+func beforeReveal(t *testing.T, lines []string) []string {
+	t.Helper()
+	last := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, viewPrefix) {
+			last = i
+		}
+	}
+	if last < 0 || !strings.Contains(lines[last], "N") {
+		t.Fatalf("guest was not shown the whole board at the end: %q", lines)
+	}
+	return lines[:last]
+}
+
+// checkNoWhitePieces fails the test if a view sent to the guest shows a
+// White piece
+func checkNoWhitePieces(t *testing.T, line string) {
+	t.Helper()
+	b, _, err := parseView(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			if piece := b.Squares[row][col]; piece.Color == board.White {
+				t.Errorf("guest sees %v on %s in %q", piece, board.Position{Row: row, Col: col}, line)
+			}
+		}
+	}
+}
+
+func TestHiddenFogOfWar(t *testing.T) {
+	lines := beforeReveal(t, playHiddenGame(t, game.FogOfWar, "Nf3\nNc3\nresign\ny\n", "a6", "h6"))
+
+	// No White piece comes in sight of Black's pieces, so the guest sees
+	// none of them until the game is over
+	var views, moves int
+	for _, line := range lines {
+		if strings.Contains(line, "Nf3") || strings.Contains(line, "Nc3") {
+			t.Errorf("guest received %q", line)
+		}
+		switch {
+		case strings.HasPrefix(line, viewPrefix):
+			checkNoWhitePieces(t, line)
+			views++
+		case strings.HasPrefix(line, movesPrefix):
+			if !slices.Contains(strings.Fields(line), "h6") {
+				t.Errorf("legal moves %q do not include h6", line)
+			}
+			moves++
+		}
+	}
+	if views != 2 || moves != 2 {
+		t.Errorf("guest received %d views and %d move lists before the end, want 2 and 2", views, moves)
+	}
+}
+
+func TestHiddenKriegspiel(t *testing.T) {
+	lines := beforeReveal(t, playHiddenGame(t, game.Kriegspiel, "Nf3\nKe2\nNc3\nresign\ny\n", "Ke7", "a6", "h6"))
+
+	// Until the game is over the guest hears the referee, but never where
+	// White's pieces went, and sees only its own pieces
+	var illegal bool
+	for _, line := range lines {
+		for _, square := range []string{"f3", "c3", "e2"} {
+			if strings.Contains(line, square) {
+				t.Errorf("guest received %q", line)
+			}
+		}
+		switch {
+		case line == sayPrefix+"Referee: Illegal":
+			illegal = true
+		case strings.HasPrefix(line, viewPrefix):
+			checkNoWhitePieces(t, line)
+		case strings.HasPrefix(line, movesPrefix):
+			t.Errorf("guest was sent its legal moves %q", line)
+		}
+	}
+	if !illegal {
+		t.Errorf("guest was not told its move was illegal: %q", lines)
+	}
+}
@@ -2,32 +2,66 @@ package ui
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/user/chess/pkg/board"
 	"github.com/user/chess/pkg/game"
 )
 
+// TakebackPolicy controls whether moves may be taken back with undo
+type TakebackPolicy int
+
+const (
+	TakebackAllowed  TakebackPolicy = iota // Either player may undo and redo moves
+	TakebackConsent                        // The player to move must agree to an undo
+	TakebackDisabled                       // Moves cannot be taken back
+)
+
+// ParseTakebackPolicy parses a policy name: allowed, consent or disabled
+func ParseTakebackPolicy(name string) (TakebackPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "allowed":
+		return TakebackAllowed, nil
+	case "consent":
+		return TakebackConsent, nil
+	case "disabled":
+		return TakebackDisabled, nil
+	}
+	return TakebackAllowed, fmt.Errorf("unknown takeback policy %q", name)
+}
+
 // UI represents the user interface for the chess game
 type UI struct {
-	game      *game.Game
-	scanner   *bufio.Scanner
-	useAscii  bool
-	whiteName string
-	blackName string
+	game           *game.Game
+	scanner        *bufio.Scanner
+	useAscii       bool
+	whiteName      string
+	blackName      string
+	takebackPolicy TakebackPolicy
+	referee        *game.Referee   // Judges the moves in Kriegspiel
+	lastMove       [3][2]int       // The announcements of each color's last move in the referee's log
+	remote         *Connection     // The opponent's computer in a network game
+	remoteColor    board.Color     // The color the network opponent plays
+	hostClock      []time.Duration // The clocks the host sent with its move, until the move is played
 }
 
 // NewUI creates a new UI
-func NewUI(game *game.Game) *UI {
-	return &UI{
-		game:      game,
+func NewUI(g *game.Game) *UI {
+	ui := &UI{
+		game:      g,
 		scanner:   bufio.NewScanner(os.Stdin),
 		useAscii:  false,
 		whiteName: "White",
 		blackName: "Black",
 	}
+	if g.Variant() == game.Kriegspiel {
+		ui.referee = game.NewReferee(g)
+	}
+	return ui
 }
 
 // SetAsciiMode sets whether to use ASCII characters instead of Unicode
@@ -39,24 +73,62 @@ func (ui *UI) SetAsciiMode(ascii bool) {
 func (ui *UI) SetPlayerNames(white, black string) {
 	ui.whiteName = white
 	ui.blackName = black
+	ui.game.SetPlayerNames(white, black)
+}
+
+// SetTakebackPolicy sets whether the undo and redo commands may be used
+func (ui *UI) SetTakebackPolicy(policy TakebackPolicy) {
+	ui.takebackPolicy = policy
+}
+
+// SetRemote makes the player of the given color play on another computer
+// over the connection. Moves cannot be taken back in a network game, and
+// the host keeps time for both sides.
+func (ui *UI) SetRemote(color board.Color, conn *Connection) {
+	ui.remote = conn
+	ui.remoteColor = color
 }
 
 // Start starts the UI
 func (ui *UI) Start() {
 	fmt.Println("Welcome to Chess in Go!")
 	fmt.Printf("Players: %s (White) vs %s (Black)\n", ui.whiteName, ui.blackName)
-	fmt.Println("Enter moves in algebraic notation (e.g., 'e2 e4' to move from e2 to e4)")
+	if variant := ui.game.Variant(); variant != game.Standard {
+		fmt.Printf("Variant: %s\n", variant.Name())
+	}
+	fmt.Println("Enter moves in algebraic notation (e.g., 'e4', 'Nf3', 'O-O', 'e8=Q') or as squares (e.g., 'e2 e4', 'e7e8q')")
+	printVariantRules(ui.game.Variant())
+	if ui.remote != nil {
+		fmt.Printf("Playing over the network; %s moves on the other computer\n", ui.playerName(ui.remoteColor))
+	} else if ui.private() {
+		fmt.Println("Pass the terminal to your opponent after each move; the board is hidden in between")
+	}
+	if ui.takebackPolicy != TakebackDisabled && ui.referee == nil && ui.remote == nil {
+		fmt.Println("Type 'undo' to take back the last move and 'redo' to replay it")
+	}
+	fmt.Println("Type 'resign' to resign, 'draw' to offer a draw, 'accept' or 'decline' to answer an offer")
+	fmt.Println("and 'claim' to claim a draw by threefold repetition or the 50-move rule")
 	fmt.Println("Type 'quit' to exit")
 
+	shown := false
 	for {
-		if ui.useAscii {
-			ui.game.Board.PrintASCII()
+		if ui.private() && ui.remote == nil {
+			if !ui.hideBoard(shown) {
+				break
+			}
+		}
+		if ui.hidden() && ui.remoteTurn() && !ui.game.IsOver() {
+			ui.showGuest()
 		} else {
-			ui.game.Board.Print()
+			if ui.referee != nil {
+				ui.printAnnouncements()
+			}
+			ui.printBoard()
+			shown = true
+			fmt.Println(ui.getGameStatus())
 		}
-		fmt.Println(ui.getGameStatus())
 
-		if ui.game.State == game.Checkmate || ui.game.State == game.Stalemate || ui.game.State == game.Draw {
+		if ui.game.IsOver() {
 			break
 		}
 
@@ -66,14 +138,195 @@ func (ui *UI) Start() {
 		}
 	}
 
-	fmt.Println("Game over")
+	ui.printGameOver()
+	if ui.hidden() {
+		ui.sendGameOver()
+	}
+}
+
+// printVariantRules explains how a variant differs from standard chess
+func printVariantRules(v game.Variant) {
+	switch v {
+	case game.Chess960:
+		fmt.Println("Castle with 'O-O' or 'O-O-O', or by moving the king onto its rook")
+	case game.KingOfTheHill:
+		fmt.Println("Win by checkmate or by bringing your king to d4, e4, d5 or e5")
+	case game.ThreeCheck:
+		fmt.Println("Win by checkmate or by giving check three times")
+	case game.Crazyhouse:
+		fmt.Println("Drop a piece from your pocket with 'N@f3' or 'P@e4'")
+	case game.Atomic:
+		fmt.Println("Captures blow up the capturing piece and every piece but pawns next to the square")
+		fmt.Println("Win by checkmate or by blowing up the enemy king")
+	case game.Antichess:
+		fmt.Println("Win by losing all your pieces or having no move. Captures are compulsory")
+		fmt.Println("and the king is an ordinary piece: there is no check and no castling")
+	case game.Horde:
+		fmt.Println("White wins by checkmate, Black by capturing every White pawn and piece")
+		fmt.Println("White pawns on the first rank may move two squares")
+	case game.RacingKings:
+		fmt.Println("Win by bringing your king to the eighth rank. No move may give check,")
+		fmt.Println("and if White gets there first Black has one move to draw by following")
+	case game.Kriegspiel:
+		fmt.Println("You see only your own pieces. The referee answers 'Illegal' to moves you cannot make")
+		fmt.Println("and announces captures, checks and pawn tries, the captures your pawns can make")
+	case game.FogOfWar:
+		fmt.Println("You see only the squares your pieces can move to or attack. There is no check:")
+		fmt.Println("win by capturing the king")
+	}
+}
+
+// printBoard prints the board, with the pockets in Crazyhouse. Until the
+// game is over, Kriegspiel shows only the pieces of the player to move
+// and Fog of War only the squares they can see.
+func (ui *UI) printBoard() {
+	if ui.referee != nil && !ui.game.IsOver() {
+		view := ui.game.Board.View(ui.game.CurrentPlayer)
+		if ui.useAscii {
+			view.PrintASCII()
+		} else {
+			view.Print()
+		}
+		return
+	}
+	if ui.game.Variant() == game.FogOfWar && !ui.game.IsOver() {
+		printMaskedBoard(ui.game.Board, ui.game.Visible(ui.game.CurrentPlayer), ui.useAscii)
+		return
+	}
+	if ui.game.Variant() == game.Crazyhouse {
+		white, black := ui.game.Pocket(board.White), ui.game.Pocket(board.Black)
+		if ui.useAscii {
+			ui.game.Board.PrintASCIIWithPockets(white, black)
+		} else {
+			ui.game.Board.PrintWithPockets(white, black)
+		}
+		return
+	}
+	if ui.useAscii {
+		ui.game.Board.PrintASCII()
+	} else {
+		ui.game.Board.Print()
+	}
+}
+
+// hideBoard clears the screen between turns of a hot-seat game of
+// Kriegspiel or Fog of War, once a board has been shown, and waits for the
+// player to move to take the terminal. It returns false at the end of
+// input.
+func (ui *UI) hideBoard(clear bool) bool {
+	if clear {
+		fmt.Print("\033[H\033[2J")
+	}
+	if ui.game.IsOver() {
+		fmt.Print("The game is over. Press Enter to see the whole board")
+	} else {
+		fmt.Printf("%s, press Enter when you are ready", ui.currentPlayerName())
+	}
+	return ui.scanner.Scan()
+}
+
+// printAnnouncements shows what the referee announced after the last move
+// of the player to move and since then
+func (ui *UI) printAnnouncements() {
+	for _, line := range ui.announcements(ui.game.CurrentPlayer) {
+		fmt.Println(line)
+	}
+}
+
+// announcements returns what the referee announced after the last move of
+// the player of the given color and since then, as shown to that player
+func (ui *UI) announcements(color board.Color) []string {
+	opponent := ui.playerName(opponentOf(color))
+
+	var lines []string
+	log := ui.referee.Log()
+	start, end := ui.lastMove[color][0], ui.lastMove[color][1]
+	for i, announcement := range log[start:] {
+		if start+i < end {
+			lines = append(lines, fmt.Sprint("Referee, after your move: ", announcement))
+		} else {
+			lines = append(lines, fmt.Sprintf("Referee, on %s's turn: %s", opponent, announcement))
+		}
+	}
+	return lines
+}
+
+// printGameOver shows the result of the game
+func (ui *UI) printGameOver() {
+	fmt.Println(ui.gameOverText())
+}
+
+// gameOverText returns the line that shows the result of the game
+func (ui *UI) gameOverText() string {
+	result := ui.game.Result()
+	if !result.IsOver() {
+		return "Game over"
+	}
+	return fmt.Sprintf("Game over: %s %s %s", ui.whiteName, result.Outcome, ui.blackName)
+}
+
+// showGuest sends the guest of a hidden game what its player sees at the
+// start of their turn: in Kriegspiel the referee's announcements and their
+// own pieces, in Fog of War the squares they can see and their legal
+// moves, and the game status. A guest who has gone is noticed when its
+// move is read.
+func (ui *UI) showGuest() {
+	if ui.referee != nil {
+		for _, line := range ui.announcements(ui.remoteColor) {
+			ui.remote.Send(sayPrefix + line)
+		}
+		ui.remote.SendView(ui.game.Board.View(ui.remoteColor), [8][8]bool{})
+		ui.remote.Send(sayPrefix + ui.getGameStatus())
+		return
+	}
+
+	ui.remote.SendView(ui.game.Board, hiddenSquares(ui.game.Visible(ui.remoteColor)))
+	ui.remote.Send(sayPrefix + ui.getGameStatus())
+
+	moves := []string{movesPrefix}
+	for _, move := range ui.game.LegalMoves() {
+		if san, err := ui.game.SAN(move); err == nil {
+			moves = append(moves, san)
+		}
+	}
+	ui.remote.Send(strings.Join(moves, " "))
+}
+
+// sendGameOver shows the guest of a hidden game the whole board and the
+// result, and tells it that nothing more will come. The guest may already
+// have gone.
+func (ui *UI) sendGameOver() {
+	if ui.referee != nil {
+		for _, line := range ui.announcements(ui.remoteColor) {
+			ui.remote.Send(sayPrefix + line)
+		}
+	}
+	if ui.game.IsOver() {
+		ui.remote.SendView(ui.game.Board, [8][8]bool{})
+		ui.remote.Send(sayPrefix + ui.getGameStatus())
+	}
+	ui.remote.Send(sayPrefix + ui.gameOverText())
+	ui.remote.Send(overLine)
 }
 
 // getGameStatus returns a string representation of the game status with player names and time
 func (ui *UI) getGameStatus() string {
 	state := ui.game.GetGameStatus()
+	if ui.game.IsOver() {
+		return state
+	}
 	timeLeft := ui.game.GetTimeLeft()
 
+	if ui.game.DrawOffer() != board.NoColor && ui.game.DrawOffer() != ui.game.CurrentPlayer {
+		state += ", draw offered"
+	}
+	if ui.game.CanClaimDraw() {
+		state += ", draw can be claimed"
+	}
+	if ui.game.Variant() == game.ThreeCheck {
+		state += fmt.Sprintf(", checks %d-%d", ui.game.Checks(board.White), ui.game.Checks(board.Black))
+	}
+
 	if timeLeft != "" {
 		if ui.game.CurrentPlayer == board.White {
 			return fmt.Sprintf("%s's turn [%s] (%s)", ui.whiteName, timeLeft, state)
@@ -87,44 +340,376 @@ func (ui *UI) getGameStatus() string {
 	return fmt.Sprintf("%s's turn (%s)", ui.blackName, state)
 }
 
-// getMove gets a move from the user
+// getMove gets a move from the player to move. In a network game what
+// the local player does is sent to the opponent, and the opponent's moves
+// and commands are read from the connection. The host of a hidden game
+// sends nothing of its own player's moves.
 func (ui *UI) getMove() string {
 	for {
-		fmt.Print("Enter move: ")
-		ui.scanner.Scan()
-		input := ui.scanner.Text()
-		input = strings.TrimSpace(input)
+		remote := ui.remoteTurn()
+		name := ui.currentPlayerName()
+		input, ok := ui.readInput(remote)
+		if !ok {
+			// End of input or a lost connection. A network opponent who
+			// is gone has abandoned the game.
+			if remote {
+				ui.abandon()
+			}
+			return "quit"
+		}
+		relay := func(line string) {
+			if !remote && !ui.hidden() {
+				ui.send(line)
+			}
+		}
 
 		if input == "quit" {
+			if remote {
+				fmt.Printf("%s left the game\n", name)
+			}
+			relay(input)
+			if ui.remote != nil {
+				ui.abandon()
+			}
 			return "quit"
 		}
 
-		// Parse move
-		parts := strings.Fields(input)
-		if len(parts) != 2 {
-			fmt.Println("Invalid input. Please enter source and destination (e.g., 'e2 e4')")
+		switch input {
+		case "undo", "redo":
+			if err := ui.takeback(input); err != nil {
+				ui.tell(fmt.Sprintf("Cannot %s: %v", input, err))
+				continue
+			}
+			return input
+		case "resign", "draw", "accept", "decline", "claim":
+			if err := ui.endGameCommand(input); err != nil {
+				ui.tell(fmt.Sprintf("Cannot %s: %v", input, err))
+				continue
+			}
+			if input != "resign" || ui.game.IsOver() {
+				relay(input)
+			}
+			if ui.game.IsOver() {
+				return input
+			}
+			// Offers and declines leave the same player to move
 			continue
 		}
 
-		from, err := board.NewPosition(parts[0])
-		if err != nil {
-			fmt.Println("Invalid source position:", err)
+		if ui.referee != nil {
+			if ui.attempt(input) {
+				return input
+			}
 			continue
 		}
 
-		to, err := board.NewPosition(parts[1])
+		// Parse move
+		move, err := ui.game.ParseMove(input)
 		if err != nil {
-			fmt.Println("Invalid destination position:", err)
+			ui.tell(fmt.Sprint("Invalid move: ", err))
 			continue
 		}
 
+		// Ask for the promotion piece unless the input named one
+		if move.PromotionType != board.Empty && !remote && !hasPromotionPiece(input) {
+			move.PromotionType = ui.getPromotion()
+		}
+
 		// Try to make the move
-		err = ui.game.MakeMove(from, to)
+		san, _ := ui.game.SAN(move)
+		err = ui.game.PlayMove(move)
 		if err != nil {
-			fmt.Println("Invalid move:", err)
+			if ui.game.IsOver() {
+				// The clock ran out before the move was made. The
+				// opponent's copy, with the same clocks, ends the game
+				// the same way.
+				ui.tell(err.Error())
+				relay(input)
+				return input
+			}
+			ui.tell(fmt.Sprint("Invalid move: ", err))
 			continue
 		}
 
+		switch {
+		case ui.hidden():
+			// Neither player learns of the other's move but through
+			// their own view
+		case remote:
+			ui.setHostClock()
+			fmt.Printf("%s played %s\n", name, san)
+		case ui.remote != nil && ui.remote.IsHost():
+			ui.sendClock()
+		}
+		relay(san)
 		return input
 	}
 }
+
+// readInput reads a line from the player to move: from the terminal, or
+// from the connection if the network opponent is to move. It returns false
+// at the end of input or when the connection is lost.
+func (ui *UI) readInput(remote bool) (string, bool) {
+	if remote {
+		fmt.Printf("Waiting for %s...\n", ui.currentPlayerName())
+		if ui.hidden() {
+			ui.remote.Send(promptLine)
+		}
+		for {
+			line, err := ui.remote.Receive()
+			if err == nil && strings.HasPrefix(line, clockPrefix) && !ui.remote.IsHost() {
+				var white, black time.Duration
+				if white, black, err = parseClock(line); err == nil {
+					ui.hostClock = []time.Duration{white, black}
+					continue
+				}
+			}
+			if err != nil {
+				fmt.Println("Connection lost:", err)
+				return "", false
+			}
+			return line, true
+		}
+	}
+
+	fmt.Print("Enter move: ")
+	if !ui.scanner.Scan() {
+		return "", false
+	}
+	return strings.TrimSpace(ui.scanner.Text()), true
+}
+
+// send passes a move or command of the local player to the network
+// opponent, if there is one
+func (ui *UI) send(line string) {
+	if ui.remote == nil {
+		return
+	}
+	if err := ui.remote.Send(line); err != nil {
+		fmt.Println("Connection lost:", err)
+	}
+}
+
+// tell shows a message to the player to move, who in a hidden game may be
+// the guest
+func (ui *UI) tell(message string) {
+	if ui.hidden() && ui.remoteTurn() {
+		ui.remote.Send(sayPrefix + message)
+		return
+	}
+	fmt.Println(message)
+}
+
+// announce shows a message to both players
+func (ui *UI) announce(message string) {
+	fmt.Println(message)
+	if ui.hidden() {
+		ui.remote.Send(sayPrefix + message)
+	}
+}
+
+// attempt tries a move through the Kriegspiel referee. It reports whether
+// the player's turn is over. Promotions are to a queen unless the move
+// names another piece.
+func (ui *UI) attempt(input string) bool {
+	color := ui.game.CurrentPlayer
+	announcements, err := ui.referee.Attempt(input)
+	if err != nil {
+		if ui.game.IsOver() {
+			// The clock ran out before the move was made
+			ui.tell(err.Error())
+			return true
+		}
+		ui.tell(fmt.Sprint("Invalid move: ", err))
+		return false
+	}
+	if len(announcements) == 1 && announcements[0].Kind == game.Illegal {
+		ui.tell(fmt.Sprint("Referee: ", announcements[0]))
+		return false
+	}
+	end := len(ui.referee.Log())
+	ui.lastMove[color] = [2]int{end - len(announcements), end}
+	return true
+}
+
+// abandon ends a network game because the player to move left it, so that
+// both computers record the same result
+func (ui *UI) abandon() {
+	if err := ui.game.Abandon(ui.game.CurrentPlayer); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// sendClock sends the host's clocks to the guest, before the move that
+// ran them is relayed
+func (ui *UI) sendClock() {
+	if ui.game.TimeControl == nil {
+		return
+	}
+	if err := ui.remote.SendClock(ui.game.TimeControl); err != nil {
+		fmt.Println("Connection lost:", err)
+	}
+}
+
+// setHostClock sets the clocks to the ones the host sent with the move
+// just played, so that the host alone decides when a flag falls
+func (ui *UI) setHostClock() {
+	if tc := ui.game.TimeControl; tc != nil && ui.hostClock != nil {
+		tc.WhiteTimeLeft, tc.BlackTimeLeft = ui.hostClock[0], ui.hostClock[1]
+	}
+	ui.hostClock = nil
+}
+
+// takeback handles the undo and redo commands according to the takeback
+// policy. An undo takes back the move of the player who is not to move, so
+// with TakebackConsent the player to move is asked to agree.
+func (ui *UI) takeback(command string) error {
+	if ui.takebackPolicy == TakebackDisabled {
+		return errors.New("takebacks are disabled in this game")
+	}
+	if ui.referee != nil {
+		return errors.New("moves cannot be taken back in Kriegspiel")
+	}
+	if ui.remote != nil {
+		return errors.New("moves cannot be taken back in a network game")
+	}
+
+	if command == "redo" {
+		return ui.game.Redo()
+	}
+
+	if !ui.game.CanUndo() {
+		return errors.New("no move to undo")
+	}
+	if ui.takebackPolicy == TakebackConsent && !ui.confirm(fmt.Sprintf("%s, allow the last move to be taken back?", ui.currentPlayerName())) {
+		return errors.New("takeback declined")
+	}
+	return ui.game.Undo()
+}
+
+// endGameCommand handles the commands that resign, offer, answer or claim
+// a draw. They act for the player to move.
+func (ui *UI) endGameCommand(command string) error {
+	color := ui.game.CurrentPlayer
+	switch command {
+	case "resign":
+		if !ui.confirm(fmt.Sprintf("%s, do you really want to resign?", ui.currentPlayerName())) {
+			return nil
+		}
+		return ui.game.Resign(color)
+	case "draw":
+		if err := ui.game.OfferDraw(color); err != nil {
+			return err
+		}
+		ui.announce(fmt.Sprintf("%s offers a draw", ui.currentPlayerName()))
+		return nil
+	case "accept":
+		return ui.game.AcceptDraw(color)
+	case "decline":
+		if ui.game.DrawOffer() == color {
+			return errors.New("you cannot decline your own offer")
+		}
+		if err := ui.game.DeclineDraw(color); err != nil {
+			return err
+		}
+		ui.announce("Draw offer declined")
+		return nil
+	case "claim":
+		reason := game.ReasonFiftyMoves
+		if ui.game.RepetitionCount() >= 3 {
+			reason = game.ReasonThreefoldRepetition
+		}
+		return ui.game.ClaimDraw(reason)
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// confirm asks a yes or no question, treating anything but yes as no. A
+// network opponent has already answered on their own computer.
+func (ui *UI) confirm(question string) bool {
+	if ui.remoteTurn() {
+		return true
+	}
+	fmt.Printf("%s (y/n): ", question)
+	if !ui.scanner.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(ui.scanner.Text()))
+	return answer == "y" || answer == "yes"
+}
+
+// currentPlayerName returns the name of the player to move
+func (ui *UI) currentPlayerName() string {
+	return ui.playerName(ui.game.CurrentPlayer)
+}
+
+// playerName returns the name of the player of the given color
+func (ui *UI) playerName(color board.Color) string {
+	if color == board.White {
+		return ui.whiteName
+	}
+	return ui.blackName
+}
+
+// remoteTurn reports whether the network opponent is to move
+func (ui *UI) remoteTurn() bool {
+	return ui.remote != nil && ui.game.CurrentPlayer == ui.remoteColor
+}
+
+// hidden reports whether this is the host of a hidden network game, which
+// alone holds the game and shows the guest only its own view
+func (ui *UI) hidden() bool {
+	return ui.remote != nil && isHidden(ui.game.Variant())
+}
+
+// private reports whether each player sees only part of the board, so that
+// the board is hidden between turns
+func (ui *UI) private() bool {
+	return ui.referee != nil || ui.game.Variant() == game.FogOfWar
+}
+
+// opponentOf returns the other color
+func opponentOf(color board.Color) board.Color {
+	if color == board.White {
+		return board.Black
+	}
+	return board.White
+}
+
+// getPromotion asks which piece a pawn should promote to
+func (ui *UI) getPromotion() board.PieceType {
+	choices := map[string]board.PieceType{
+		"q": board.Queen,
+		"r": board.Rook,
+		"b": board.Bishop,
+		"n": board.Knight,
+	}
+	prompt, help := "q, r, b, n", "q (queen), r (rook), b (bishop) or n (knight)"
+	if ui.game.Variant() == game.Antichess {
+		// Kings are ordinary pieces that pawns may promote to
+		choices["k"] = board.King
+		prompt, help = "q, r, b, n, k", "q (queen), r (rook), b (bishop), n (knight) or k (king)"
+	}
+
+	for {
+		fmt.Printf("Promote to (%s) [q]: ", prompt)
+		if !ui.scanner.Scan() {
+			return board.Queen
+		}
+		input := strings.ToLower(strings.TrimSpace(ui.scanner.Text()))
+		if input == "" {
+			return board.Queen
+		}
+		if pieceType, ok := choices[input]; ok {
+			return pieceType
+		}
+		fmt.Println("Invalid piece. Choose " + help)
+	}
+}
+
+// hasPromotionPiece reports whether a move input names a promotion piece,
+// as in "e8=N" or "e7e8n"
+func hasPromotionPiece(input string) bool {
+	input = strings.TrimRight(input, "+#!? ")
+	return input != "" && strings.ContainsRune("qrbnkQRBNK", rune(input[len(input)-1]))
+}